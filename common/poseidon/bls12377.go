@@ -8,17 +8,24 @@ package poseidon
 // The input and output are modified to ingest Goldilocks field elements.
 
 import (
+	"fmt"
 	"math/big"
 
 	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
 )
 
 const BLS12377_SPONGE_WIDTH int = 4
 const BLS12377_SPONGE_RATE int = 3
 
+// BLS12377_PACKED_BITS bounds the bits of the packed limbs of a state
+// element, so that packings are below the field modulus.
+const BLS12377_PACKED_BITS int = 252
+
 type BLS12377Chip struct {
-	api frontend.API `gnark:"-"`
+	api  frontend.API `gnark:"-"`
+	chip *Chip
 }
 
 type BLS12377State = [BLS12377_SPONGE_WIDTH]frontend.Variable
@@ -29,18 +36,39 @@ func NewBLS12377Chip(api frontend.API) *BLS12377Chip {
 		panic("Gnark compiler not set to BLS12377 scalar field")
 	}
 
-	return &BLS12377Chip{api: api}
+	chip, err := NewChip(api, BLS12377ChipParams())
+	if err != nil {
+		panic(err)
+	}
+	return &BLS12377Chip{api: api, chip: chip}
 }
 
 func (c *BLS12377Chip) Poseidon(state BLS12377State) BLS12377State {
-	state = c.ark(state, 0)
-	state = c.fullRounds(state, true)
-	state = c.partialRounds(state)
-	state = c.fullRounds(state, false)
+	copy(state[:], c.chip.Poseidon(state[:]))
 	return state
 }
 
 func (c *BLS12377Chip) HashNoPad(input []frontend.Variable) BLS12377HashOut {
+	return c.hashPacked(input, 64)
+}
+
+// HashNoPadChecked hashes input like HashNoPad, after checking with
+// rangecheck.New(api) that every element fits in limbBits bits. Each state
+// element packs as many limbs as fit in BLS12377_PACKED_BITS bits, so the
+// packing is injective; with 64-bit limbs it is the one of HashNoPad. Like
+// HashNoPad, inputs differing only by trailing zeros still collide.
+func (c *BLS12377Chip) HashNoPadChecked(input []frontend.Variable, limbBits int) BLS12377HashOut {
+	checker := rangecheck.New(c.api)
+	for _, limb := range input {
+		checker.Check(limb, limbBits)
+	}
+	return c.hashPacked(input, limbBits)
+}
+
+// hashPacked packs the limbs of input, limbBits bits each, into the rate and
+// permutes, one rate at a time.
+func (c *BLS12377Chip) hashPacked(input []frontend.Variable, limbBits int) BLS12377HashOut {
+	perElement := packedLimbs(limbBits)
 	state := BLS12377State{
 		frontend.Variable(0),
 		frontend.Variable(0),
@@ -48,19 +76,16 @@ func (c *BLS12377Chip) HashNoPad(input []frontend.Variable) BLS12377HashOut {
 		frontend.Variable(0),
 	}
 
-	two_to_32 := new(big.Int).SetInt64(1 << 32)
-	two_to_64 := new(big.Int).Mul(two_to_32, two_to_32)
-
-	for i := 0; i < len(input); i += BLS12377_SPONGE_RATE * 3 {
-		endI := c.min(len(input), i+BLS12377_SPONGE_RATE*3)
+	for i := 0; i < len(input); i += BLS12377_SPONGE_RATE * perElement {
+		endI := c.min(len(input), i+BLS12377_SPONGE_RATE*perElement)
 		rateChunk := input[i:endI]
-		for j, stateIdx := 0, 0; j < len(rateChunk); j, stateIdx = j+3, stateIdx+1 {
-			endJ := c.min(len(rateChunk), j+3)
+		for j, stateIdx := 0, 0; j < len(rateChunk); j, stateIdx = j+perElement, stateIdx+1 {
+			endJ := c.min(len(rateChunk), j+perElement)
 			chunk := rateChunk[j:endJ]
 
 			inter := frontend.Variable(0)
 			for k := 0; k < len(chunk); k++ {
-				inter = c.api.MulAcc(inter, chunk[k], new(big.Int).Exp(two_to_64, big.NewInt(int64(k)), nil))
+				inter = c.api.MulAcc(inter, chunk[k], new(big.Int).Lsh(big.NewInt(1), uint(limbBits*k)))
 			}
 
 			state[stateIdx+1] = inter
@@ -115,6 +140,15 @@ func (c *BLS12377Chip) ToVec(hash BLS12377HashOut) []frontend.Variable {
 	return returnElements
 }
 
+// packedLimbs returns the number of limbs of limbBits bits packed into a
+// state element.
+func packedLimbs(limbBits int) int {
+	if limbBits < 1 || limbBits > BLS12377_PACKED_BITS {
+		panic(fmt.Sprintf("limbs of %d bits cannot be packed, expected 1 to %d bits", limbBits, BLS12377_PACKED_BITS))
+	}
+	return BLS12377_PACKED_BITS / limbBits
+}
+
 func (c *BLS12377Chip) min(x, y int) int {
 	if x < y {
 		return x
@@ -122,83 +156,3 @@ func (c *BLS12377Chip) min(x, y int) int {
 
 	return y
 }
-
-func (c *BLS12377Chip) fullRounds(state BLS12377State, isFirst bool) BLS12377State {
-	for i := 0; i < BLS12377_FULL_ROUNDS/2-1; i++ {
-		state = c.exp5state(state)
-		if isFirst {
-			state = c.ark(state, (i+1)*BLS12377_SPONGE_WIDTH)
-		} else {
-			state = c.ark(state, (BLS12377_FULL_ROUNDS/2+1)*BLS12377_SPONGE_WIDTH+BLS12377_PARTIAL_ROUNDS+i*BLS12377_SPONGE_WIDTH)
-		}
-		state = c.mix(state, mMatrixBLS12377)
-	}
-
-	state = c.exp5state(state)
-	if isFirst {
-		state = c.ark(state, (BLS12377_FULL_ROUNDS/2)*BLS12377_SPONGE_WIDTH)
-		state = c.mix(state, pMatrixBLS12377)
-	} else {
-		state = c.mix(state, mMatrixBLS12377)
-	}
-
-	return state
-}
-
-func (c *BLS12377Chip) partialRounds(state BLS12377State) BLS12377State {
-	for i := 0; i < BLS12377_PARTIAL_ROUNDS; i++ {
-		state[0] = c.exp5(state[0])
-		state[0] = c.api.Add(state[0], cConstantsBLS12377[(BLS12377_FULL_ROUNDS/2+1)*BLS12377_SPONGE_WIDTH+i])
-
-		newState0 := frontend.Variable(0)
-		for j := 0; j < BLS12377_SPONGE_WIDTH; j++ {
-			newState0 = c.api.MulAcc(newState0, sConstantsBLS12377[(BLS12377_SPONGE_WIDTH*2-1)*i+j], state[j])
-		}
-
-		for k := 1; k < BLS12377_SPONGE_WIDTH; k++ {
-			state[k] = c.api.MulAcc(state[k], state[0], sConstantsBLS12377[(BLS12377_SPONGE_WIDTH*2-1)*i+BLS12377_SPONGE_WIDTH+k-1])
-		}
-		state[0] = newState0
-	}
-
-	return state
-}
-
-func (c *BLS12377Chip) ark(state BLS12377State, it int) BLS12377State {
-	var result BLS12377State
-
-	for i := 0; i < len(state); i++ {
-		result[i] = c.api.Add(state[i], cConstantsBLS12377[it+i])
-	}
-
-	return result
-}
-
-func (c *BLS12377Chip) exp5(x frontend.Variable) frontend.Variable {
-	x2 := c.api.Mul(x, x)
-	x4 := c.api.Mul(x2, x2)
-	return c.api.Mul(x4, x)
-}
-
-func (c *BLS12377Chip) exp5state(state BLS12377State) BLS12377State {
-	for i := 0; i < BLS12377_SPONGE_WIDTH; i++ {
-		state[i] = c.exp5(state[i])
-	}
-	return state
-}
-
-func (c *BLS12377Chip) mix(state_ BLS12377State, constantMatrix [][]*big.Int) BLS12377State {
-	var result BLS12377State
-
-	for i := 0; i < BLS12377_SPONGE_WIDTH; i++ {
-		result[i] = frontend.Variable(0)
-	}
-
-	for i := 0; i < BLS12377_SPONGE_WIDTH; i++ {
-		for j := 0; j < BLS12377_SPONGE_WIDTH; j++ {
-			result[i] = c.api.MulAcc(result[i], constantMatrix[j][i], state_[j])
-		}
-	}
-
-	return result
-}
@@ -0,0 +1,241 @@
+package poseidon
+
+// Native, out-of-circuit twin of BLS12377Chip over gnark-crypto's BLS12-377
+// fr.Element, with the same limb packing and round structure, to compute the
+// values a circuit is expected to produce.
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+)
+
+type BLS12377NativeState = [BLS12377_SPONGE_WIDTH]fr.Element
+
+// BLS12377Native mirrors the methods of BLS12377Chip.
+type BLS12377Native struct{}
+
+func NewBLS12377Native() *BLS12377Native {
+	nativeConstantsOnce.Do(initNativeConstants)
+	return &BLS12377Native{}
+}
+
+var (
+	nativeConstantsOnce sync.Once
+	cConstantsNative    []fr.Element
+	sConstantsNative    []fr.Element
+	mMatrixNative       [][]fr.Element
+	pMatrixNative       [][]fr.Element
+)
+
+func initNativeConstants() {
+	toElements := func(values []*big.Int) []fr.Element {
+		res := make([]fr.Element, len(values))
+		for i, v := range values {
+			res[i].SetBigInt(v)
+		}
+		return res
+	}
+
+	cConstantsNative = toElements(cConstantsBLS12377)
+	sConstantsNative = toElements(sConstantsBLS12377)
+	for i := 0; i < BLS12377_SPONGE_WIDTH; i++ {
+		mMatrixNative = append(mMatrixNative, toElements(mMatrixBLS12377[i]))
+		pMatrixNative = append(pMatrixNative, toElements(pMatrixBLS12377[i]))
+	}
+}
+
+// BLS12377Elements converts values, e.g. the limbs of emulated elements, to
+// the inputs of the native hash functions.
+func BLS12377Elements(values ...*big.Int) []fr.Element {
+	res := make([]fr.Element, len(values))
+	for i, v := range values {
+		res[i].SetBigInt(v)
+	}
+	return res
+}
+
+func (n *BLS12377Native) Poseidon(state BLS12377NativeState) BLS12377NativeState {
+	state = n.ark(state, 0)
+	state = n.fullRounds(state, true)
+	state = n.partialRounds(state)
+	state = n.fullRounds(state, false)
+	return state
+}
+
+func (n *BLS12377Native) HashNoPad(input []fr.Element) fr.Element {
+	return n.hashPacked(input, 64)
+}
+
+// HashNoPadChecked mirrors BLS12377Chip.HashNoPadChecked, returning an
+// error for the inputs the circuit rejects.
+func (n *BLS12377Native) HashNoPadChecked(input []fr.Element, limbBits int) (fr.Element, error) {
+	packedLimbs(limbBits) // panics on invalid widths, like the chip
+	for i := range input {
+		var v big.Int
+		if input[i].BigInt(&v).BitLen() > limbBits {
+			return fr.Element{}, fmt.Errorf("input %d has %d bits, expected at most %d", i, v.BitLen(), limbBits)
+		}
+	}
+	return n.hashPacked(input, limbBits), nil
+}
+
+func (n *BLS12377Native) hashPacked(input []fr.Element, limbBits int) fr.Element {
+	var state BLS12377NativeState
+	perElement := packedLimbs(limbBits)
+
+	for i := 0; i < len(input); i += BLS12377_SPONGE_RATE * perElement {
+		endI := min(len(input), i+BLS12377_SPONGE_RATE*perElement)
+		rateChunk := input[i:endI]
+		for j, stateIdx := 0, 0; j < len(rateChunk); j, stateIdx = j+perElement, stateIdx+1 {
+			endJ := min(len(rateChunk), j+perElement)
+			state[stateIdx+1] = packBits(rateChunk[j:endJ], limbBits)
+		}
+
+		state = n.Poseidon(state)
+	}
+
+	return state[0]
+}
+
+func (n *BLS12377Native) HashOrNoop(input []fr.Element) fr.Element {
+	if len(input) <= 3 {
+		return pack(input)
+	}
+	return n.HashNoPad(input)
+}
+
+func (n *BLS12377Native) TwoToOne(left fr.Element, right fr.Element) fr.Element {
+	var inputs BLS12377NativeState
+	inputs[2] = left
+	inputs[3] = right
+	state := n.Poseidon(inputs)
+	return state[0]
+}
+
+func (n *BLS12377Native) ToVec(hash fr.Element) []fr.Element {
+	var h big.Int
+	hash.BigInt(&h)
+
+	returnElements := []fr.Element{}
+
+	// Split into 7 byte chunks, since 8 byte chunks can result in collisions
+	chunkSize := 56
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(chunkSize)), big.NewInt(1))
+	for i := 0; i < fr.Bits; i += chunkSize {
+		var chunk big.Int
+		chunk.Rsh(&h, uint(i)).And(&chunk, mask)
+
+		var e fr.Element
+		e.SetBigInt(&chunk)
+		returnElements = append(returnElements, e)
+	}
+
+	return returnElements
+}
+
+// pack returns Σ input[k]⋅2^(64k), the way the chip packs up to three
+// Goldilocks elements into one field element.
+func pack(input []fr.Element) fr.Element {
+	return packBits(input, 64)
+}
+
+// packBits returns Σ input[k]⋅2^(limbBits⋅k).
+func packBits(input []fr.Element, limbBits int) fr.Element {
+	var res, factor, shift fr.Element
+	factor.SetOne()
+	shift.SetBigInt(new(big.Int).Lsh(big.NewInt(1), uint(limbBits)))
+
+	for k := range input {
+		var t fr.Element
+		t.Mul(&input[k], &factor)
+		res.Add(&res, &t)
+		factor.Mul(&factor, &shift)
+	}
+	return res
+}
+
+func (n *BLS12377Native) fullRounds(state BLS12377NativeState, isFirst bool) BLS12377NativeState {
+	for i := 0; i < BLS12377_FULL_ROUNDS/2-1; i++ {
+		state = n.exp5state(state)
+		if isFirst {
+			state = n.ark(state, (i+1)*BLS12377_SPONGE_WIDTH)
+		} else {
+			state = n.ark(state, (BLS12377_FULL_ROUNDS/2+1)*BLS12377_SPONGE_WIDTH+BLS12377_PARTIAL_ROUNDS+i*BLS12377_SPONGE_WIDTH)
+		}
+		state = n.mix(state, mMatrixNative)
+	}
+
+	state = n.exp5state(state)
+	if isFirst {
+		state = n.ark(state, (BLS12377_FULL_ROUNDS/2)*BLS12377_SPONGE_WIDTH)
+		state = n.mix(state, pMatrixNative)
+	} else {
+		state = n.mix(state, mMatrixNative)
+	}
+
+	return state
+}
+
+func (n *BLS12377Native) partialRounds(state BLS12377NativeState) BLS12377NativeState {
+	for i := 0; i < BLS12377_PARTIAL_ROUNDS; i++ {
+		state[0] = n.exp5(state[0])
+		state[0].Add(&state[0], &cConstantsNative[(BLS12377_FULL_ROUNDS/2+1)*BLS12377_SPONGE_WIDTH+i])
+
+		var newState0 fr.Element
+		for j := 0; j < BLS12377_SPONGE_WIDTH; j++ {
+			var t fr.Element
+			t.Mul(&sConstantsNative[(BLS12377_SPONGE_WIDTH*2-1)*i+j], &state[j])
+			newState0.Add(&newState0, &t)
+		}
+
+		for k := 1; k < BLS12377_SPONGE_WIDTH; k++ {
+			var t fr.Element
+			t.Mul(&state[0], &sConstantsNative[(BLS12377_SPONGE_WIDTH*2-1)*i+BLS12377_SPONGE_WIDTH+k-1])
+			state[k].Add(&state[k], &t)
+		}
+		state[0] = newState0
+	}
+
+	return state
+}
+
+func (n *BLS12377Native) ark(state BLS12377NativeState, it int) BLS12377NativeState {
+	var result BLS12377NativeState
+
+	for i := 0; i < len(state); i++ {
+		result[i].Add(&state[i], &cConstantsNative[it+i])
+	}
+
+	return result
+}
+
+func (n *BLS12377Native) exp5(x fr.Element) fr.Element {
+	var x2, x4 fr.Element
+	x2.Square(&x)
+	x4.Square(&x2)
+	return *x4.Mul(&x4, &x)
+}
+
+func (n *BLS12377Native) exp5state(state BLS12377NativeState) BLS12377NativeState {
+	for i := 0; i < BLS12377_SPONGE_WIDTH; i++ {
+		state[i] = n.exp5(state[i])
+	}
+	return state
+}
+
+func (n *BLS12377Native) mix(state_ BLS12377NativeState, constantMatrix [][]fr.Element) BLS12377NativeState {
+	var result BLS12377NativeState
+
+	for i := 0; i < BLS12377_SPONGE_WIDTH; i++ {
+		for j := 0; j < BLS12377_SPONGE_WIDTH; j++ {
+			var t fr.Element
+			t.Mul(&constantMatrix[j][i], &state_[j])
+			result[i].Add(&result[i], &t)
+		}
+	}
+
+	return result
+}
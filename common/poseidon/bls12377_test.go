@@ -0,0 +1,111 @@
+package poseidon_test
+
+import (
+	"fmt"
+	"gnark-circuit-gen/common/poseidon"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// maxLen is the largest number of inputs hashed by the tests of every
+// input length.
+const maxLen = 32
+
+// newRand returns the source of the random inputs, seeded so that failures
+// reproduce.
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(1))
+}
+
+func randomElements(rng *rand.Rand, modulus *big.Int, n int) []*big.Int {
+	res := make([]*big.Int, n)
+	for i := range res {
+		res[i] = new(big.Int).Rand(rng, modulus)
+	}
+	return res
+}
+
+func frElements(values []*big.Int) []fr.Element {
+	res := make([]fr.Element, len(values))
+	for i := range values {
+		res[i].SetBigInt(values[i])
+	}
+	return res
+}
+
+func variables[T any](values []T) []frontend.Variable {
+	res := make([]frontend.Variable, len(values))
+	for i := range values {
+		res[i] = values[i]
+	}
+	return res
+}
+
+// differentialCircuit asserts that the chip maps Input, Left and Right to
+// the outputs computed natively.
+type differentialCircuit struct {
+	Input       []frontend.Variable
+	Left, Right frontend.Variable
+
+	HashNoPad  frontend.Variable   `gnark:",public"`
+	HashOrNoop frontend.Variable   `gnark:",public"`
+	TwoToOne   frontend.Variable   `gnark:",public"`
+	ToVec      []frontend.Variable `gnark:",public"`
+}
+
+func (c *differentialCircuit) Define(api frontend.API) error {
+	chip := poseidon.NewBLS12377Chip(api)
+
+	hash := chip.HashNoPad(c.Input)
+	api.AssertIsEqual(hash, c.HashNoPad)
+	api.AssertIsEqual(chip.HashOrNoop(c.Input), c.HashOrNoop)
+	api.AssertIsEqual(chip.TwoToOne(c.Left, c.Right), c.TwoToOne)
+
+	vec := chip.ToVec(hash)
+	if len(vec) != len(c.ToVec) {
+		return fmt.Errorf("ToVec returns %d elements, natively %d", len(vec), len(c.ToVec))
+	}
+	for i := range vec {
+		api.AssertIsEqual(vec[i], c.ToVec[i])
+	}
+	return nil
+}
+
+// TestBLS12377Differential checks that the chip and its native twin agree
+// on HashNoPad, HashOrNoop and ToVec of random Goldilocks elements, for
+// every input length from 1 to maxLen, and on TwoToOne of random field
+// elements.
+func TestBLS12377Differential(t *testing.T) {
+	assert := test.NewAssert(t)
+	rng := newRand()
+	native := poseidon.NewBLS12377Native()
+	goldilocks := new(big.Int).SetUint64(0xffffffff00000001)
+
+	for n := 1; n <= maxLen; n++ {
+		input := frElements(randomElements(rng, goldilocks, n))
+		pair := frElements(randomElements(rng, fr.Modulus(), 2))
+
+		hash := native.HashNoPad(input)
+		vec := native.ToVec(hash)
+		assignment := &differentialCircuit{
+			Input:      variables(input),
+			Left:       pair[0],
+			Right:      pair[1],
+			HashNoPad:  hash,
+			HashOrNoop: native.HashOrNoop(input),
+			TwoToOne:   native.TwoToOne(pair[0], pair[1]),
+			ToVec:      variables(vec),
+		}
+		circuit := &differentialCircuit{
+			Input: make([]frontend.Variable, n),
+			ToVec: make([]frontend.Variable, len(vec)),
+		}
+		assert.NoError(test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()), "%d inputs", n)
+	}
+}
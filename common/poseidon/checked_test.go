@@ -0,0 +1,58 @@
+package poseidon_test
+
+import (
+	"gnark-circuit-gen/common/poseidon"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// checkedCircuit asserts that HashNoPadChecked maps Input, limbs of
+// limbBits bits, to the hash computed natively.
+type checkedCircuit struct {
+	Input []frontend.Variable
+	Hash  frontend.Variable `gnark:",public"`
+
+	limbBits int `gnark:"-"`
+}
+
+func (c *checkedCircuit) Define(api frontend.API) error {
+	chip := poseidon.NewBLS12377Chip(api)
+	api.AssertIsEqual(chip.HashNoPadChecked(c.Input, c.limbBits), c.Hash)
+	return nil
+}
+
+// TestBLS12377HashNoPadChecked checks that HashNoPadChecked agrees with
+// its native twin on maxLen random limbs of the Goldilocks width of
+// HashNoPad, the one of emulated limbs, and widths packing two and a
+// single limb per state element; that it equals HashNoPad for 64-bit limbs,
+// and that the native twin rejects a limb one bit too wide.
+func TestBLS12377HashNoPadChecked(t *testing.T) {
+	assert := test.NewAssert(t)
+	rng := newRand()
+	native := poseidon.NewBLS12377Native()
+
+	for _, limbBits := range []int{64, 32, 120, poseidon.BLS12377_PACKED_BITS} {
+		bound := new(big.Int).Lsh(big.NewInt(1), uint(limbBits))
+		input := poseidon.BLS12377Elements(randomElements(rng, bound, maxLen)...)
+
+		hash, err := native.HashNoPadChecked(input, limbBits)
+		assert.NoError(err)
+		if plain := native.HashNoPad(input); limbBits == 64 {
+			assert.True(hash.Equal(&plain), "HashNoPadChecked of 64-bit limbs differs from HashNoPad")
+		}
+
+		wide := append([]fr.Element{}, input...)
+		wide[len(wide)-1].SetBigInt(bound)
+		_, err = native.HashNoPadChecked(wide, limbBits)
+		assert.Error(err, "HashNoPadChecked accepts a limb of %d bits for %d-bit limbs", limbBits+1, limbBits)
+
+		assignment := &checkedCircuit{Input: variables(input), Hash: hash}
+		circuit := &checkedCircuit{Input: make([]frontend.Variable, maxLen), limbBits: limbBits}
+		assert.NoError(test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()), "%d-bit limbs", limbBits)
+	}
+}
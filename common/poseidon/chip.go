@@ -0,0 +1,166 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Chip computes the Poseidon permutation of Params in a circuit, for any
+// width and field. BLS12377Chip is the chip of BLS12377ChipParams.
+type Chip struct {
+	api    frontend.API `gnark:"-"`
+	params *Params
+}
+
+// NewChip returns a chip for params, which must be over the field of the
+// circuit.
+func NewChip(api frontend.API, params *Params) (*Chip, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if api.Compiler().Field().Cmp(params.Modulus) != 0 {
+		return nil, fmt.Errorf("poseidon parameters are over another field than the circuit")
+	}
+	return &Chip{api: api, params: params}, nil
+}
+
+// Params returns the parameters of the chip.
+func (c *Chip) Params() *Params {
+	return c.params
+}
+
+// Poseidon returns the permutation of state, which holds Width elements.
+func (c *Chip) Poseidon(state []frontend.Variable) []frontend.Variable {
+	if len(state) != c.params.Width {
+		panic(fmt.Sprintf("poseidon state of %d elements, expected %d", len(state), c.params.Width))
+	}
+	state = append([]frontend.Variable{}, state...)
+	if c.params.Optimized != nil {
+		return c.optimized(state)
+	}
+
+	p := c.params
+	for r := 0; r < p.FullRounds+p.PartialRounds; r++ {
+		state = c.ark(state, p.RoundConstants, r*p.Width)
+		if r < p.FullRounds/2 || r >= p.FullRounds/2+p.PartialRounds {
+			state = c.sboxState(state)
+		} else {
+			state[0] = c.sbox(state[0])
+		}
+		state = c.mix(state, p.MDS, false)
+	}
+	return state
+}
+
+// Hash absorbs input Width-1 elements at a time into the rate, overwriting
+// it, and permutes, like BLS12377Chip.HashNoPad without the packing. It
+// returns the first element of the state.
+func (c *Chip) Hash(input []frontend.Variable) frontend.Variable {
+	rate := c.params.Width - 1
+	state := make([]frontend.Variable, c.params.Width)
+	for i := range state {
+		state[i] = frontend.Variable(0)
+	}
+
+	for i := 0; i < len(input); i += rate {
+		copy(state[1:], input[i:min(len(input), i+rate)])
+		state = c.Poseidon(state)
+	}
+	return state[0]
+}
+
+// TwoToOne compresses left and right, placed in the last two elements of a
+// zero state, like BLS12377Chip.TwoToOne.
+func (c *Chip) TwoToOne(left, right frontend.Variable) frontend.Variable {
+	state := make([]frontend.Variable, c.params.Width)
+	for i := range state {
+		state[i] = frontend.Variable(0)
+	}
+	state[c.params.Width-2], state[c.params.Width-1] = left, right
+	return c.Poseidon(state)[0]
+}
+
+// optimized computes the permutation with the constants of
+// Params.Optimized, as go-iden3-crypto does.
+func (c *Chip) optimized(state []frontend.Variable) []frontend.Variable {
+	p, o, t := c.params, c.params.Optimized, c.params.Width
+
+	state = c.ark(state, o.C, 0)
+	for i := 0; i < p.FullRounds/2-1; i++ {
+		state = c.sboxState(state)
+		state = c.ark(state, o.C, (i+1)*t)
+		state = c.mix(state, o.M, true)
+	}
+	state = c.sboxState(state)
+	state = c.ark(state, o.C, (p.FullRounds/2)*t)
+	state = c.mix(state, o.P, true)
+
+	for i := 0; i < p.PartialRounds; i++ {
+		state[0] = c.sbox(state[0])
+		state[0] = c.api.Add(state[0], o.C[(p.FullRounds/2+1)*t+i])
+
+		newState0 := frontend.Variable(0)
+		for j := 0; j < t; j++ {
+			newState0 = c.api.MulAcc(newState0, o.S[(t*2-1)*i+j], state[j])
+		}
+
+		for k := 1; k < t; k++ {
+			state[k] = c.api.MulAcc(state[k], state[0], o.S[(t*2-1)*i+t+k-1])
+		}
+		state[0] = newState0
+	}
+
+	for i := 0; i < p.FullRounds/2-1; i++ {
+		state = c.sboxState(state)
+		state = c.ark(state, o.C, (p.FullRounds/2+1)*t+p.PartialRounds+i*t)
+		state = c.mix(state, o.M, true)
+	}
+	state = c.sboxState(state)
+	return c.mix(state, o.M, true)
+}
+
+func (c *Chip) ark(state []frontend.Variable, constants []*big.Int, it int) []frontend.Variable {
+	result := make([]frontend.Variable, len(state))
+	for i := range state {
+		result[i] = c.api.Add(state[i], constants[it+i])
+	}
+	return result
+}
+
+// sbox returns x^Alpha, by square and multiply.
+func (c *Chip) sbox(x frontend.Variable) frontend.Variable {
+	alpha := big.NewInt(int64(c.params.Alpha))
+	res := x
+	for i := alpha.BitLen() - 2; i >= 0; i-- {
+		res = c.api.Mul(res, res)
+		if alpha.Bit(i) == 1 {
+			res = c.api.Mul(res, x)
+		}
+	}
+	return res
+}
+
+func (c *Chip) sboxState(state []frontend.Variable) []frontend.Variable {
+	for i := range state {
+		state[i] = c.sbox(state[i])
+	}
+	return state
+}
+
+// mix multiplies state by m, or by the transpose of m.
+func (c *Chip) mix(state []frontend.Variable, m [][]*big.Int, transposed bool) []frontend.Variable {
+	result := make([]frontend.Variable, len(state))
+	for i := range result {
+		result[i] = frontend.Variable(0)
+		for j := range state {
+			if transposed {
+				result[i] = c.api.MulAcc(result[i], m[j][i], state[j])
+			} else {
+				result[i] = c.api.MulAcc(result[i], m[i][j], state[j])
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,154 @@
+package poseidon
+
+// Constant generation of the reference implementation of Poseidon:
+//
+// 		https://extgit.iaik.tugraz.at/krypto/hadeshash/-/blob/master/code/generate_params_poseidon.sage
+//
+// The round constants and the MDS matrix are drawn from the Grain LFSR,
+// seeded with the parameters. The constants of BLS12377Chip were generated
+// this way, for a width of 4 with 8 full and 56 partial rounds.
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// grain is the Grain LFSR of the reference implementation, over a prime
+// field with x^alpha S-boxes.
+type grain struct {
+	state [80]uint8
+	head  int
+}
+
+func newGrain(fieldSize, width, fullRounds, partialRounds int) *grain {
+	g := &grain{}
+	bits := make([]uint8, 0, 80)
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, uint8(v>>i)&1)
+		}
+	}
+	appendBits(1, 2) // prime field
+	appendBits(0, 4) // x^alpha S-box
+	appendBits(fieldSize, 12)
+	appendBits(width, 12)
+	appendBits(fullRounds, 10)
+	appendBits(partialRounds, 10)
+	appendBits(1<<30-1, 30)
+	copy(g.state[:], bits)
+
+	for i := 0; i < 160; i++ {
+		g.next()
+	}
+	return g
+}
+
+// next shifts the register and returns the new bit.
+func (g *grain) next() uint8 {
+	at := func(i int) uint8 { return g.state[(g.head+i)%80] }
+	bit := at(62) ^ at(51) ^ at(38) ^ at(23) ^ at(13) ^ at(0)
+	g.state[g.head] = bit
+	g.head = (g.head + 1) % 80
+	return bit
+}
+
+// bits returns an n-bit integer, most significant bit first, keeping the
+// second of every pair of bits whose first one is set.
+func (g *grain) bits(n int) *big.Int {
+	res := new(big.Int)
+	for i := 0; i < n; i++ {
+		b1, b2 := g.next(), g.next()
+		for b1 == 0 {
+			b1, b2 = g.next(), g.next()
+		}
+		res.Lsh(res, 1)
+		res.SetBit(res, 0, uint(b2))
+	}
+	return res
+}
+
+// element returns an n-bit integer below modulus, by rejection sampling.
+func (g *grain) element(modulus *big.Int) *big.Int {
+	for {
+		if v := g.bits(modulus.BitLen()); v.Cmp(modulus) < 0 {
+			return v
+		}
+	}
+}
+
+// roundConstants draws the constants of every round, in order.
+func (g *grain) roundConstants(modulus *big.Int, width, rounds int) []*big.Int {
+	res := make([]*big.Int, width*rounds)
+	for i := range res {
+		res[i] = g.element(modulus)
+	}
+	return res
+}
+
+// cauchyMatrix draws the Cauchy matrix 1/(x_i+y_j) of the reference,
+// retrying until the x_i and y_j are distinct and every x_i+y_j is
+// invertible. Unlike the round constants, x_i and y_j are reduced instead
+// of rejected when above the modulus.
+func (g *grain) cauchyMatrix(modulus *big.Int, width int) [][]*big.Int {
+	for {
+		values := make([]*big.Int, 2*width)
+		for distinct := false; !distinct; {
+			seen := map[string]bool{}
+			distinct = true
+			for i := range values {
+				values[i] = g.bits(modulus.BitLen())
+				values[i].Mod(values[i], modulus)
+				distinct = distinct && !seen[values[i].String()]
+				seen[values[i].String()] = true
+			}
+		}
+
+		xs, ys := values[:width], values[width:]
+		m := make([][]*big.Int, width)
+		ok := true
+		for i := 0; i < width && ok; i++ {
+			m[i] = make([]*big.Int, width)
+			for j := 0; j < width && ok; j++ {
+				sum := new(big.Int).Add(xs[i], ys[j])
+				m[i][j] = sum.ModInverse(sum.Mod(sum, modulus), modulus)
+				ok = m[i][j] != nil
+			}
+		}
+		if ok {
+			return m
+		}
+	}
+}
+
+// Generate returns the parameters of the reference implementation for the
+// prime field of modulus: the round constants and the first secure MDS
+// matrix drawn from the Grain LFSR for the given width and numbers of
+// rounds.
+func Generate(modulus *big.Int, width, alpha, fullRounds, partialRounds int) (*Params, error) {
+	p := &Params{
+		Modulus:       new(big.Int).Set(modulus),
+		Width:         width,
+		Alpha:         alpha,
+		FullRounds:    fullRounds,
+		PartialRounds: partialRounds,
+	}
+	if err := p.validateShape(); err != nil {
+		return nil, err
+	}
+	if !modulus.ProbablyPrime(20) {
+		return nil, fmt.Errorf("modulus %s is not prime", modulus)
+	}
+	if modulus.BitLen() >= 1<<12 || fullRounds >= 1<<10 || partialRounds >= 1<<10 {
+		return nil, fmt.Errorf("parameters do not fit in the seed of the Grain LFSR")
+	}
+
+	g := newGrain(modulus.BitLen(), width, fullRounds, partialRounds)
+	p.RoundConstants = g.roundConstants(modulus, width, fullRounds+partialRounds)
+	for p.MDS = g.cauchyMatrix(modulus, width); !secureMDS(p.MDS, modulus); {
+		p.MDS = g.cauchyMatrix(modulus, width)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
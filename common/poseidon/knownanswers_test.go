@@ -0,0 +1,231 @@
+package poseidon_test
+
+import (
+	"encoding/json"
+	"flag"
+	"gnark-circuit-gen/common/poseidon"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// update recomputes the outputs of testdata/bls12377.json instead of
+// checking them, see updateVectors.
+var update = flag.Bool("update", false, "recompute the outputs of testdata/bls12377.json with poseidon.Generate")
+
+// vectors is the format of testdata/bls12377.json. Field elements are
+// decimal strings.
+type vectors struct {
+	// Source states how the outputs were computed.
+	Source      string `json:"source"`
+	Permutation []struct {
+		Input  []string `json:"input"`
+		Output []string `json:"output"`
+	} `json:"permutation"`
+	// sponge hashes of Goldilocks elements, without padding
+	HashNoPad []struct {
+		Input  []string `json:"input"`
+		Output string   `json:"output"`
+	} `json:"hash_no_pad"`
+	TwoToOne []struct {
+		Left   string `json:"left"`
+		Right  string `json:"right"`
+		Output string `json:"output"`
+	} `json:"two_to_one"`
+}
+
+const vectorsSource = "Outputs recomputed by go test ./common/poseidon -run TestBLS12377KnownAnswers -update: " +
+	"the textbook permutation of poseidon.Generate for the BLS12377Chip parameters, with the round constants and MDS matrix " +
+	"drawn from the Grain LFSR of the reference implementation, independently of bls12377_constants.go, " +
+	"of the optimized permutation and of the sponge of the chip and its native twin. " +
+	"The permutation outputs agree with testdata/bls12377_reference.json, computed by another implementation."
+
+// updateVectors recomputes the outputs of v from its inputs with the
+// parameters of Generate, rather than with the code under test: the
+// textbook permutation, and the sponge of HashNoPad written out again,
+// three 64-bit limbs per element of the rate, which is overwritten.
+func updateVectors(t *testing.T, v *vectors) {
+	t.Helper()
+	chip := poseidon.BLS12377ChipParams()
+	params, err := poseidon.Generate(chip.Modulus, chip.Width, chip.Alpha, chip.FullRounds, chip.PartialRounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decimals := func(values []*big.Int) []string {
+		res := make([]string, len(values))
+		for i := range values {
+			res[i] = values[i].String()
+		}
+		return res
+	}
+	bigInts := func(values ...string) []*big.Int {
+		res := make([]*big.Int, len(values))
+		for i := range values {
+			res[i] = parse(t, values[i])[0].BigInt(new(big.Int))
+		}
+		return res
+	}
+
+	for i := range v.Permutation {
+		v.Permutation[i].Output = decimals(params.Permute(bigInts(v.Permutation[i].Input...)))
+	}
+	for i := range v.HashNoPad {
+		input := bigInts(v.HashNoPad[i].Input...)
+		state := bigInts("0", "0", "0", "0")
+		for len(input) > 0 {
+			for j := 1; j < params.Width && len(input) > 0; j++ {
+				state[j] = new(big.Int)
+				for k := 0; k < 3 && len(input) > 0; k++ {
+					state[j].Add(state[j], new(big.Int).Lsh(input[0], uint(64*k)))
+					input = input[1:]
+				}
+			}
+			state = params.Permute(state)
+		}
+		v.HashNoPad[i].Output = state[0].String()
+	}
+	for i := range v.TwoToOne {
+		state := bigInts("0", "0", v.TwoToOne[i].Left, v.TwoToOne[i].Right)
+		v.TwoToOne[i].Output = params.Permute(state)[0].String()
+	}
+	v.Source = vectorsSource
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("testdata/bls12377.json", append(data, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// knownAnswerCircuit asserts that the chip maps the inputs of every vector
+// to its public output.
+type knownAnswerCircuit struct {
+	Permutation []permutationWires
+	HashNoPad   []hashWires
+	TwoToOne    []twoToOneWires
+}
+
+type permutationWires struct {
+	Input  poseidon.BLS12377State
+	Output poseidon.BLS12377State `gnark:",public"`
+}
+
+type hashWires struct {
+	Input  []frontend.Variable
+	Output frontend.Variable `gnark:",public"`
+}
+
+type twoToOneWires struct {
+	Left, Right frontend.Variable
+	Output      frontend.Variable `gnark:",public"`
+}
+
+func (c *knownAnswerCircuit) Define(api frontend.API) error {
+	chip := poseidon.NewBLS12377Chip(api)
+
+	for _, v := range c.Permutation {
+		output := chip.Poseidon(v.Input)
+		for i := range output {
+			api.AssertIsEqual(output[i], v.Output[i])
+		}
+	}
+	for _, v := range c.HashNoPad {
+		api.AssertIsEqual(chip.HashNoPad(v.Input), v.Output)
+	}
+	for _, v := range c.TwoToOne {
+		api.AssertIsEqual(chip.TwoToOne(v.Left, v.Right), v.Output)
+	}
+	return nil
+}
+
+// TestBLS12377KnownAnswers checks that both the chip and its native twin
+// reproduce testdata/bls12377.json, and the permutation vectors of
+// testdata/bls12377_reference.json, which testdata/reference.py computes
+// without the code of the package and -update leaves alone. The vectors
+// must not be updated when the check fails: a mismatch means that a
+// constant or the round structure changed.
+func TestBLS12377KnownAnswers(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	data, err := os.ReadFile("testdata/bls12377.json")
+	assert.NoError(err)
+	var v vectors
+	assert.NoError(json.Unmarshal(data, &v))
+	if *update {
+		updateVectors(t, &v)
+	}
+	data, err = os.ReadFile("testdata/bls12377_reference.json")
+	assert.NoError(err)
+	var reference vectors
+	assert.NoError(json.Unmarshal(data, &reference))
+	assert.NotEmpty(reference.Permutation, "no reference vector")
+	v.Permutation = append(v.Permutation, reference.Permutation...)
+	native := poseidon.NewBLS12377Native()
+
+	circuit := &knownAnswerCircuit{
+		Permutation: make([]permutationWires, len(v.Permutation)),
+		HashNoPad:   make([]hashWires, len(v.HashNoPad)),
+		TwoToOne:    make([]twoToOneWires, len(v.TwoToOne)),
+	}
+	assignment := &knownAnswerCircuit{
+		Permutation: make([]permutationWires, len(v.Permutation)),
+		HashNoPad:   make([]hashWires, len(v.HashNoPad)),
+		TwoToOne:    make([]twoToOneWires, len(v.TwoToOne)),
+	}
+
+	for i, vector := range v.Permutation {
+		input, output := parse(t, vector.Input...), parse(t, vector.Output...)
+		assert.Len(input, poseidon.BLS12377_SPONGE_WIDTH, "permutation vector %d", i)
+		assert.Len(output, poseidon.BLS12377_SPONGE_WIDTH, "permutation vector %d", i)
+
+		var state poseidon.BLS12377NativeState
+		copy(state[:], input)
+		state = native.Poseidon(state)
+		for j := range state {
+			assert.True(state[j].Equal(&output[j]), "permutation vector %d: native output %d is %s, expected %s", i, j, state[j].String(), output[j].String())
+			assignment.Permutation[i].Input[j] = input[j]
+			assignment.Permutation[i].Output[j] = output[j]
+		}
+	}
+
+	for i, vector := range v.HashNoPad {
+		input, output := parse(t, vector.Input...), parse(t, vector.Output)[0]
+
+		hash := native.HashNoPad(input)
+		assert.True(hash.Equal(&output), "hash vector %d (%d inputs): native output is %s, expected %s", i, len(input), hash.String(), output.String())
+		circuit.HashNoPad[i].Input = make([]frontend.Variable, len(input))
+		assignment.HashNoPad[i] = hashWires{Input: variables(input), Output: output}
+	}
+
+	for i, vector := range v.TwoToOne {
+		values := parse(t, vector.Left, vector.Right, vector.Output)
+
+		hash := native.TwoToOne(values[0], values[1])
+		assert.True(hash.Equal(&values[2]), "two-to-one vector %d: native output is %s, expected %s", i, hash.String(), values[2].String())
+		assignment.TwoToOne[i] = twoToOneWires{Left: values[0], Right: values[1], Output: values[2]}
+	}
+
+	assert.NoError(test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()))
+}
+
+// parse reads decimal field elements, rejecting non-canonical ones so that
+// a vector cannot silently be reduced.
+func parse(t *testing.T, values ...string) []fr.Element {
+	t.Helper()
+	res := make([]fr.Element, len(values))
+	for i, s := range values {
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok || v.Sign() < 0 || v.Cmp(fr.Modulus()) >= 0 {
+			t.Fatalf("invalid field element %q", s)
+		}
+		res[i].SetBigInt(v)
+	}
+	return res
+}
@@ -0,0 +1,250 @@
+package poseidon
+
+import (
+	"math/big"
+)
+
+// Matrices and vectors over the prime field of a modulus, for the
+// derivation of the optimized constants and the checks of the MDS matrix.
+
+func identity(n int) [][]*big.Int {
+	res := make([][]*big.Int, n)
+	for i := range res {
+		res[i] = make([]*big.Int, n)
+		for j := range res[i] {
+			res[i][j] = new(big.Int)
+		}
+		res[i][i].SetInt64(1)
+	}
+	return res
+}
+
+func transpose(m [][]*big.Int) [][]*big.Int {
+	res := make([][]*big.Int, len(m[0]))
+	for i := range res {
+		res[i] = make([]*big.Int, len(m))
+		for j := range res[i] {
+			res[i][j] = new(big.Int).Set(m[j][i])
+		}
+	}
+	return res
+}
+
+// subMatrix returns the rows and columns of m from the given indices on.
+func subMatrix(m [][]*big.Int, row, col int) [][]*big.Int {
+	res := make([][]*big.Int, len(m)-row)
+	for i := range res {
+		res[i] = m[row+i][col:]
+	}
+	return res
+}
+
+func matMul(a, b [][]*big.Int, modulus *big.Int) [][]*big.Int {
+	res := make([][]*big.Int, len(a))
+	for i := range res {
+		res[i] = make([]*big.Int, len(b[0]))
+		for j := range res[i] {
+			res[i][j] = new(big.Int)
+			for k := range b {
+				res[i][j].Add(res[i][j], new(big.Int).Mul(a[i][k], b[k][j]))
+			}
+			res[i][j].Mod(res[i][j], modulus)
+		}
+	}
+	return res
+}
+
+// vecMat returns the row vector v times m.
+func vecMat(v []*big.Int, m [][]*big.Int, modulus *big.Int) []*big.Int {
+	return matMul([][]*big.Int{v}, m, modulus)[0]
+}
+
+// matVec returns m times the column vector v.
+func matVec(m [][]*big.Int, v []*big.Int, modulus *big.Int) []*big.Int {
+	col := make([][]*big.Int, len(v))
+	for i := range v {
+		col[i] = []*big.Int{v[i]}
+	}
+	res := matMul(m, col, modulus)
+	out := make([]*big.Int, len(res))
+	for i := range res {
+		out[i] = res[i][0]
+	}
+	return out
+}
+
+// matInverse returns the inverse of m by Gauss-Jordan elimination, or nil
+// if m is singular.
+func matInverse(m [][]*big.Int, modulus *big.Int) [][]*big.Int {
+	n := len(m)
+	a := make([][]*big.Int, n)
+	for i := range a {
+		a[i] = make([]*big.Int, 2*n)
+		for j := 0; j < n; j++ {
+			a[i][j] = new(big.Int).Mod(m[i][j], modulus)
+			a[i][n+j] = new(big.Int)
+		}
+		a[i][n+i].SetInt64(1)
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for row := col; row < n && pivot < 0; row++ {
+			if a[row][col].Sign() != 0 {
+				pivot = row
+			}
+		}
+		if pivot < 0 {
+			return nil
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+
+		inv := new(big.Int).ModInverse(a[col][col], modulus)
+		for j := range a[col] {
+			a[col][j].Mul(a[col][j], inv).Mod(a[col][j], modulus)
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Int).Set(a[row][col])
+			for j := range a[row] {
+				a[row][j].Sub(a[row][j], new(big.Int).Mul(f, a[col][j])).Mod(a[row][j], modulus)
+			}
+		}
+	}
+
+	res := make([][]*big.Int, n)
+	for i := range res {
+		res[i] = a[i][n:]
+	}
+	return res
+}
+
+// isScalar reports whether m is a multiple of the identity.
+func isScalar(m [][]*big.Int) bool {
+	for i := range m {
+		for j := range m[i] {
+			if (i == j && m[i][j].Cmp(m[0][0]) != 0) || (i != j && m[i][j].Sign() != 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// kernel returns a basis of the vectors x such that rows·x = 0, and the
+// free indices of the reduced row echelon form of rows: the basis vector k
+// is 1 at free[k] and 0 at the other free indices.
+func kernel(rows [][]*big.Int, modulus *big.Int) (basis [][]*big.Int, free []int) {
+	n := len(rows[0])
+	a := make([][]*big.Int, len(rows))
+	for i := range a {
+		a[i] = make([]*big.Int, n)
+		for j := range a[i] {
+			a[i][j] = new(big.Int).Mod(rows[i][j], modulus)
+		}
+	}
+
+	var pivots []int
+	for col, rank := 0, 0; col < n; col++ {
+		pivot := -1
+		for row := rank; row < len(a) && pivot < 0; row++ {
+			if a[row][col].Sign() != 0 {
+				pivot = row
+			}
+		}
+		if pivot < 0 {
+			free = append(free, col)
+			continue
+		}
+		a[rank], a[pivot] = a[pivot], a[rank]
+
+		inv := new(big.Int).ModInverse(a[rank][col], modulus)
+		for j := range a[rank] {
+			a[rank][j].Mul(a[rank][j], inv).Mod(a[rank][j], modulus)
+		}
+		for row := range a {
+			if row == rank || a[row][col].Sign() == 0 {
+				continue
+			}
+			f := new(big.Int).Set(a[row][col])
+			for j := range a[row] {
+				a[row][j].Sub(a[row][j], new(big.Int).Mul(f, a[rank][j])).Mod(a[row][j], modulus)
+			}
+		}
+		pivots = append(pivots, col)
+		rank++
+	}
+
+	for _, f := range free {
+		v := make([]*big.Int, n)
+		for j := range v {
+			v[j] = new(big.Int)
+		}
+		v[f].SetInt64(1)
+		for row, col := range pivots {
+			v[col].Neg(a[row][f]).Mod(v[col], modulus)
+		}
+		basis = append(basis, v)
+	}
+	return basis, free
+}
+
+// charPoly returns the characteristic polynomial of m, coefficients of the
+// lowest degree first, by reduction to the Hessenberg form.
+func charPoly(m [][]*big.Int, modulus *big.Int) []*big.Int {
+	n := len(m)
+	h := make([][]*big.Int, n)
+	for i := range h {
+		h[i] = make([]*big.Int, n)
+		for j := range h[i] {
+			h[i][j] = new(big.Int).Mod(m[i][j], modulus)
+		}
+	}
+
+	for j := 0; j+2 < n; j++ {
+		pivot := -1
+		for i := j + 1; i < n && pivot < 0; i++ {
+			if h[i][j].Sign() != 0 {
+				pivot = i
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		h[j+1], h[pivot] = h[pivot], h[j+1]
+		for i := range h {
+			h[i][j+1], h[i][pivot] = h[i][pivot], h[i][j+1]
+		}
+
+		inv := new(big.Int).ModInverse(h[j+1][j], modulus)
+		for i := j + 2; i < n; i++ {
+			u := new(big.Int).Mul(h[i][j], inv)
+			u.Mod(u, modulus)
+			if u.Sign() == 0 {
+				continue
+			}
+			for k := range h[i] {
+				h[i][k].Sub(h[i][k], new(big.Int).Mul(u, h[j+1][k])).Mod(h[i][k], modulus)
+			}
+			for k := range h {
+				h[k][j+1].Add(h[k][j+1], new(big.Int).Mul(u, h[k][i])).Mod(h[k][j+1], modulus)
+			}
+		}
+	}
+
+	// characteristic polynomials of the leading k×k submatrices
+	polys := [][]*big.Int{{big.NewInt(1)}}
+	for k := 0; k < n; k++ {
+		next := polyMul(polys[k], []*big.Int{new(big.Int).Neg(h[k][k]), big.NewInt(1)}, modulus)
+		product := big.NewInt(1)
+		for i := k - 1; i >= 0; i-- {
+			product.Mul(product, h[i+1][i]).Mod(product, modulus)
+			f := new(big.Int).Mul(product, h[i][k])
+			next = polySub(next, polyScale(polys[i], f, modulus), modulus)
+		}
+		polys = append(polys, next)
+	}
+	return polys[n]
+}
@@ -0,0 +1,87 @@
+package poseidon
+
+import (
+	"math/big"
+)
+
+// secureMDS reports whether the partial rounds with the MDS matrix m have no
+// infinitely long subspace trail, by the three algorithms of
+// https://eprint.iacr.org/2020/500 which the reference implementation runs
+// on its Cauchy matrices, for the single S-box of the partial rounds.
+func secureMDS(m [][]*big.Int, modulus *big.Int) bool {
+	return algorithm1(m, modulus) && algorithm2(m, modulus) && algorithm3(m, modulus)
+}
+
+// algorithm1 checks the subspace trails of period i = 1, ..., t-1: m^i is
+// not a multiple of the identity, which leaves every subspace invariant,
+// and no eigenvector of m^i keeps the S-box input zero over the i rounds of
+// a period.
+//
+// Such eigenvectors lie in the largest subspace of these states which is
+// invariant under m^i, so it is enough to look for an eigenvalue of m^i
+// restricted to it. By Cayley-Hamilton, that subspace is the same for every
+// period: the states keeping the S-box input zero forever, the kernel of
+// the first rows of m^0, ..., m^(t-1).
+func algorithm1(m [][]*big.Int, modulus *big.Int) bool {
+	t := len(m)
+
+	rows := make([][]*big.Int, t)
+	rows[0] = identity(t)[0]
+	for i := 1; i < t; i++ {
+		rows[i] = vecMat(rows[i-1], m, modulus)
+	}
+	basis, free := kernel(rows, modulus)
+
+	mi := m
+	for i := 1; i < t; i++ {
+		if isScalar(mi) {
+			return false
+		}
+		if len(basis) > 0 {
+			// coordinates of mi on the basis, given by its free entries
+			restricted := make([][]*big.Int, len(basis))
+			for l := range restricted {
+				restricted[l] = make([]*big.Int, len(basis))
+			}
+			for k, v := range basis {
+				image := matVec(mi, v, modulus)
+				for l := range restricted {
+					restricted[l][k] = image[free[l]]
+				}
+			}
+			if hasRoot(charPoly(restricted, modulus), modulus) {
+				return false
+			}
+		}
+		mi = matMul(mi, m, modulus)
+	}
+	return true
+}
+
+// algorithm2 checks that no proper subspace containing the S-box input is
+// invariant under m: the smallest one, spanned by e_0, m·e_0, m^2·e_0, ...,
+// must be the whole space.
+func algorithm2(m [][]*big.Int, modulus *big.Int) bool {
+	t := len(m)
+	vectors := make([][]*big.Int, t)
+	vectors[0] = identity(t)[0]
+	for i := 1; i < t; i++ {
+		vectors[i] = matVec(m, vectors[i-1], modulus)
+	}
+	basis, _ := kernel(vectors, modulus)
+	return len(basis) == 0
+}
+
+// algorithm3 runs algorithm2 on m^2, ..., m^(4t), for the subspace trails
+// over several rounds.
+func algorithm3(m [][]*big.Int, modulus *big.Int) bool {
+	t := len(m)
+	mr := m
+	for r := 2; r <= 4*t; r++ {
+		mr = matMul(mr, m, modulus)
+		if !algorithm2(mr, modulus) {
+			return false
+		}
+	}
+	return true
+}
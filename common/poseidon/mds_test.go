@@ -0,0 +1,68 @@
+package poseidon
+
+import (
+	"math/big"
+	"testing"
+)
+
+func matrix(rows ...[]int64) [][]*big.Int {
+	res := make([][]*big.Int, len(rows))
+	for i, row := range rows {
+		res[i] = make([]*big.Int, len(row))
+		for j := range row {
+			res[i][j] = big.NewInt(row[j])
+		}
+	}
+	return res
+}
+
+// TestSecureMDS checks that each algorithm rejects a matrix with the
+// subspace trail it looks for, and accepts the others.
+func TestSecureMDS(t *testing.T) {
+	modulus := big.NewInt(101)
+	for _, tc := range []struct {
+		name                   string
+		m                      [][]*big.Int
+		alg1, alg2, alg3, safe bool
+	}{
+		// e_1 is an eigenvector with a zero S-box input
+		{"lower triangular", matrix([]int64{2, 0}, []int64{1, 3}), false, true, true, false},
+		// e_0 spans an invariant subspace
+		{"upper triangular", matrix([]int64{2, 1}, []int64{0, 3}), true, false, false, false},
+		// m^2 is the identity
+		{"swap", matrix([]int64{0, 1}, []int64{1, 0}), true, true, false, false},
+		// every subspace is invariant
+		{"scalar", matrix([]int64{2, 0}, []int64{0, 2}), false, false, false, false},
+		{"cauchy", matrix([]int64{1, 2, 3}, []int64{4, 5, 7}, []int64{11, 13, 17}), true, true, true, true},
+	} {
+		if got := algorithm1(tc.m, modulus); got != tc.alg1 {
+			t.Errorf("%s: algorithm 1 returns %v", tc.name, got)
+		}
+		if got := algorithm2(tc.m, modulus); got != tc.alg2 {
+			t.Errorf("%s: algorithm 2 returns %v", tc.name, got)
+		}
+		if got := algorithm3(tc.m, modulus); got != tc.alg3 {
+			t.Errorf("%s: algorithm 3 returns %v", tc.name, got)
+		}
+		if got := secureMDS(tc.m, modulus); got != tc.safe {
+			t.Errorf("%s: secureMDS returns %v", tc.name, got)
+		}
+	}
+}
+
+func TestHasRoot(t *testing.T) {
+	modulus := big.NewInt(7)
+	for _, tc := range []struct {
+		m    [][]*big.Int
+		root bool
+	}{
+		// x^2 + 1 has no root modulo 7
+		{matrix([]int64{0, 6}, []int64{1, 0}), false},
+		// x^2 - 2 has the roots 3 and 4
+		{matrix([]int64{0, 2}, []int64{1, 0}), true},
+	} {
+		if got := hasRoot(charPoly(tc.m, modulus), modulus); got != tc.root {
+			t.Errorf("characteristic polynomial of %v: hasRoot returns %v", tc.m, got)
+		}
+	}
+}
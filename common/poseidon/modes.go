@@ -0,0 +1,152 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Domain separation tags. Hash, TwoToOne and the hashes of BLS12377Chip
+// use a zero capacity element state[0], DOMAIN_NONE. The modes below take
+// the tag as an argument, so that hashes of different purposes do not
+// collide even on equal inputs, and absorb by addition into state[1:].
+const (
+	DOMAIN_NONE uint64 = 0
+	// 2^2-1, the tag of the Poseidon paper for binary Merkle trees
+	DOMAIN_TWO_TO_ONE uint64 = 3
+	// a tag for the Fiat-Shamir transcripts of BLS12377Sponge
+	DOMAIN_TRANSCRIPT uint64 = 1<<32 + 2
+)
+
+// Mode tags, folded into the capacity element as mode·2^64 + domain, so
+// that two modes never share a capacity whatever their domain tags, nor
+// with the zero capacity of Hash and TwoToOne.
+const (
+	MODE_PAD10 uint64 = iota + 1
+	MODE_LENGTH_PREFIXED
+	MODE_TWO_TO_ONE
+)
+
+// HashPad10 appends 1 and as many zeros as fill the last block to input,
+// absorbs it Width-1 elements at a time into a state whose capacity is
+// domain tagged with MODE_PAD10, and returns the first element of the
+// state. Unlike Hash, inputs differing only by trailing zeros do not
+// collide.
+func (c *Chip) HashPad10(domain uint64, input []frontend.Variable) frontend.Variable {
+	padded := append(append([]frontend.Variable{}, input...), 1)
+	return c.absorb(MODE_PAD10, domain, padded)
+}
+
+// HashLengthPrefixed absorbs the number of elements of input followed by
+// input, zero-padded to a whole number of blocks, into a state whose
+// capacity is domain tagged with MODE_LENGTH_PREFIXED, and returns the
+// first element of the state.
+func (c *Chip) HashLengthPrefixed(domain uint64, input []frontend.Variable) frontend.Variable {
+	prefixed := append([]frontend.Variable{len(input)}, input...)
+	return c.absorb(MODE_LENGTH_PREFIXED, domain, prefixed)
+}
+
+// HashVarLen returns HashPad10(domain, input[:length]) for a length set at
+// proving time, which is asserted to be between 0 and len(input). All the
+// blocks of input are permuted and the state after the block holding the
+// padding is selected.
+func (c *Chip) HashVarLen(domain uint64, input []frontend.Variable, length frontend.Variable) frontend.Variable {
+	api, rate := c.api, c.params.Width-1
+
+	// isLength[k] = 1 iff k = length; exactly one of them is set when length
+	// is between 0 and len(input)
+	isLength := make([]frontend.Variable, len(input)+1)
+	found := frontend.Variable(0)
+	for k := range isLength {
+		isLength[k] = api.IsZero(api.Sub(length, k))
+		found = api.Add(found, isLength[k])
+	}
+	api.AssertIsEqual(found, 1)
+
+	// padded[k] = input[k] before length, 1 at length and 0 after it
+	padded := make([]frontend.Variable, len(isLength))
+	before := frontend.Variable(1)
+	for k := range padded {
+		before = api.Sub(before, isLength[k])
+		padded[k] = isLength[k]
+		if k < len(input) {
+			padded[k] = api.MulAcc(padded[k], before, input[k])
+		}
+	}
+	padded = padBlock(padded, rate)
+
+	state := c.capacity(MODE_PAD10, domain)
+	res := frontend.Variable(0)
+	for i := 0; i < len(padded); i += rate {
+		for j := 0; j < rate; j++ {
+			state[j+1] = api.Add(state[j+1], padded[i+j])
+		}
+		state = c.Poseidon(state)
+
+		last := frontend.Variable(0)
+		for k := i; k < i+rate && k < len(isLength); k++ {
+			last = api.Add(last, isLength[k])
+		}
+		res = api.MulAcc(res, last, state[0])
+	}
+	return res
+}
+
+// TwoToOneDomain compresses left and right, placed in the last two elements
+// of a state whose capacity is domain tagged with MODE_TWO_TO_ONE, like
+// TwoToOne. The width must be at least 3.
+func (c *Chip) TwoToOneDomain(domain uint64, left, right frontend.Variable) frontend.Variable {
+	if c.params.Width < 3 {
+		panic(fmt.Sprintf("poseidon width %d leaves no room for a domain tag", c.params.Width))
+	}
+	state := c.capacity(MODE_TWO_TO_ONE, domain)
+	state[c.params.Width-2], state[c.params.Width-1] = left, right
+	return c.Poseidon(state)[0]
+}
+
+// capacity returns a zero state with domain tagged with mode in its
+// capacity element.
+func (c *Chip) capacity(mode, domain uint64) []frontend.Variable {
+	state := make([]frontend.Variable, c.params.Width)
+	state[0] = capacity(mode, domain)
+	for i := 1; i < len(state); i++ {
+		state[i] = frontend.Variable(0)
+	}
+	return state
+}
+
+// absorb adds input, zero-padded to a whole number of blocks, into the rate
+// of a state whose capacity is domain tagged with mode, permuting after
+// each block.
+func (c *Chip) absorb(mode, domain uint64, input []frontend.Variable) frontend.Variable {
+	rate := c.params.Width - 1
+	padded := padBlock(input, rate)
+	state := c.capacity(mode, domain)
+	for i := 0; i < len(padded); i += rate {
+		for j := 0; j < rate; j++ {
+			state[j+1] = c.api.Add(state[j+1], padded[i+j])
+		}
+		state = c.Poseidon(state)
+	}
+	return state[0]
+}
+
+// capacity returns mode·2^64 + domain.
+func capacity(mode, domain uint64) *big.Int {
+	res := new(big.Int).SetUint64(mode)
+	return res.Lsh(res, 64).Add(res, new(big.Int).SetUint64(domain))
+}
+
+// padBlock appends zeros to input up to a non-zero multiple of rate.
+func padBlock(input []frontend.Variable, rate int) []frontend.Variable {
+	for len(input) < paddedLen(len(input), rate) {
+		input = append(input, frontend.Variable(0))
+	}
+	return input
+}
+
+// paddedLen returns the smallest non-zero multiple of rate from n on.
+func paddedLen(n, rate int) int {
+	return max(1, (n+rate-1)/rate) * rate
+}
@@ -0,0 +1,55 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Native twins of the sponge modes of Chip. The native of HashVarLen is
+// HashPad10 of the first length elements.
+
+// HashPad10 is the native Chip.HashPad10.
+func (p *Params) HashPad10(domain uint64, input []*big.Int) *big.Int {
+	padded := append(append([]*big.Int{}, input...), big.NewInt(1))
+	return p.absorb(MODE_PAD10, domain, padded)
+}
+
+// HashLengthPrefixed is the native Chip.HashLengthPrefixed.
+func (p *Params) HashLengthPrefixed(domain uint64, input []*big.Int) *big.Int {
+	prefixed := append([]*big.Int{big.NewInt(int64(len(input)))}, input...)
+	return p.absorb(MODE_LENGTH_PREFIXED, domain, prefixed)
+}
+
+// TwoToOneDomain is the native Chip.TwoToOneDomain.
+func (p *Params) TwoToOneDomain(domain uint64, left, right *big.Int) *big.Int {
+	if p.Width < 3 {
+		panic(fmt.Sprintf("poseidon width %d leaves no room for a domain tag", p.Width))
+	}
+	state := p.capacity(MODE_TWO_TO_ONE, domain)
+	state[p.Width-2], state[p.Width-1] = left, right
+	return p.Permute(state)[0]
+}
+
+func (p *Params) capacity(mode, domain uint64) []*big.Int {
+	state := make([]*big.Int, p.Width)
+	state[0] = capacity(mode, domain)
+	for i := 1; i < len(state); i++ {
+		state[i] = new(big.Int)
+	}
+	return state
+}
+
+// absorb adds input, zero-padded to a whole number of blocks, into the rate
+// of a state whose capacity is domain tagged with mode, permuting after
+// each block.
+func (p *Params) absorb(mode, domain uint64, input []*big.Int) *big.Int {
+	rate := p.Width - 1
+	state := p.capacity(mode, domain)
+	for i := 0; i < paddedLen(len(input), rate); i += rate {
+		for j := i; j < len(input) && j < i+rate; j++ {
+			state[j-i+1] = new(big.Int).Add(state[j-i+1], input[j])
+		}
+		state = p.Permute(state)
+	}
+	return state[0]
+}
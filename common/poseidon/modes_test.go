@@ -0,0 +1,105 @@
+package poseidon_test
+
+import (
+	"gnark-circuit-gen/common/poseidon"
+	"gnark-circuit-gen/pkg/curves"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// modesCircuit asserts that the padded and domain-separated sponge modes of
+// the generic chip map Input, Length, Left and Right to the outputs
+// computed natively.
+type modesCircuit struct {
+	Input       []frontend.Variable
+	Length      frontend.Variable
+	Left, Right frontend.Variable
+
+	Pad10          frontend.Variable `gnark:",public"`
+	LengthPrefixed frontend.Variable `gnark:",public"`
+	VarLen         frontend.Variable `gnark:",public"`
+	TwoToOne       frontend.Variable `gnark:",public"`
+
+	params *poseidon.Params `gnark:"-"`
+}
+
+func (c *modesCircuit) Define(api frontend.API) error {
+	chip, err := poseidon.NewChip(api, c.params)
+	if err != nil {
+		return err
+	}
+
+	api.AssertIsEqual(chip.HashPad10(poseidon.DOMAIN_NONE, c.Input), c.Pad10)
+	api.AssertIsEqual(chip.HashLengthPrefixed(poseidon.DOMAIN_NONE, c.Input), c.LengthPrefixed)
+	api.AssertIsEqual(chip.HashVarLen(poseidon.DOMAIN_NONE, c.Input, c.Length), c.VarLen)
+	api.AssertIsEqual(chip.TwoToOneDomain(poseidon.DOMAIN_TWO_TO_ONE, c.Left, c.Right), c.TwoToOne)
+	return nil
+}
+
+// TestModes checks the padded and domain-separated sponge modes with the
+// parameters of BLS12377Chip and the BN254 preset of width 3: natively,
+// that trailing zeros, domain tags and modes change the hash, and in a
+// circuit of maxLen inputs, that the chip agrees with the native modes for
+// every length of HashVarLen from 0 to maxLen and rejects maxLen+1.
+func TestModes(t *testing.T) {
+	bn254, err := poseidon.Preset(ecc.BN254, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		curve  ecc.ID
+		params *poseidon.Params
+	}{{ecc.BLS12_377, poseidon.BLS12377ChipParams()}, {ecc.BN254, bn254}} {
+		t.Run(curves.Name(tc.curve), func(t *testing.T) {
+			checkModes(t, tc.curve, tc.params)
+		})
+	}
+}
+
+func checkModes(t *testing.T, curve ecc.ID, params *poseidon.Params) {
+	assert := test.NewAssert(t)
+	rng := newRand()
+
+	input := randomElements(rng, params.Modulus, maxLen)
+	padded := append(append([]*big.Int{}, input...), new(big.Int))
+	assert.NotEqual(params.HashPad10(poseidon.DOMAIN_NONE, input), params.HashPad10(poseidon.DOMAIN_NONE, padded), "HashPad10 ignores a trailing zero")
+	assert.NotEqual(params.HashLengthPrefixed(poseidon.DOMAIN_NONE, input), params.HashLengthPrefixed(poseidon.DOMAIN_NONE, padded), "HashLengthPrefixed ignores a trailing zero")
+	assert.NotEqual(params.HashPad10(poseidon.DOMAIN_NONE, input), params.HashPad10(poseidon.DOMAIN_TRANSCRIPT, input), "HashPad10 ignores the domain tag")
+
+	// with the same domain tag, [1] is absorbed as [1, 1] by both modes,
+	// and as left and right by TwoToOneDomain for a width of 3
+	one := []*big.Int{big.NewInt(1)}
+	prefixed := params.HashLengthPrefixed(poseidon.DOMAIN_NONE, one)
+	assert.NotEqual(params.HashPad10(poseidon.DOMAIN_NONE, one), prefixed, "HashPad10 and HashLengthPrefixed collide on [1]")
+	if params.Width == 3 {
+		assert.NotEqual(params.TwoToOneDomain(poseidon.DOMAIN_NONE, one[0], one[0]), prefixed, "HashLengthPrefixed collides with TwoToOneDomain on [1]")
+	}
+
+	circuit := &modesCircuit{Input: make([]frontend.Variable, maxLen), params: params}
+	for length := 0; length <= maxLen+1; length++ {
+		input := randomElements(rng, params.Modulus, maxLen)
+		pair := randomElements(rng, params.Modulus, 2)
+		assert.NotEqual(params.TwoToOne(pair[0], pair[1]), params.TwoToOneDomain(poseidon.DOMAIN_NONE, pair[0], pair[1]), "TwoToOneDomain collides with TwoToOne")
+
+		assignment := &modesCircuit{
+			Input:          variables(input),
+			Length:         length,
+			Left:           pair[0],
+			Right:          pair[1],
+			Pad10:          params.HashPad10(poseidon.DOMAIN_NONE, input),
+			LengthPrefixed: params.HashLengthPrefixed(poseidon.DOMAIN_NONE, input),
+			VarLen:         params.HashPad10(poseidon.DOMAIN_NONE, input[:min(length, maxLen)]),
+			TwoToOne:       params.TwoToOneDomain(poseidon.DOMAIN_TWO_TO_ONE, pair[0], pair[1]),
+		}
+		err := test.IsSolved(circuit, assignment, curve.ScalarField())
+		if length <= maxLen {
+			assert.NoError(err, "length %d", length)
+		} else {
+			assert.Error(err, "HashVarLen accepts length %d beyond its %d inputs", length, maxLen)
+		}
+	}
+}
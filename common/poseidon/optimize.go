@@ -0,0 +1,98 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Optimize returns a copy of p with the constants of the equivalent
+// optimized permutation, as derived by the reference implementation: the
+// round constants of the partial rounds are moved up, so that each of them
+// only adds one constant after its S-box, and the MDS matrix of the partial
+// rounds is factored into a pre-sparse matrix, applied once after the first
+// full rounds, and one sparse matrix per partial round.
+//
+// Matrices are in the transposed layout of OptimizedConstants, the one of
+// bls12377_constants.go.
+func (p *Params) Optimize() (*Params, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if p.Optimized != nil {
+		return nil, fmt.Errorf("poseidon parameters are already optimized")
+	}
+
+	t, rf, rp := p.Width, p.FullRounds/2, p.PartialRounds
+	m := transpose(p.MDS)
+	mInv := matInverse(m, p.Modulus)
+	if mInv == nil {
+		return nil, fmt.Errorf("MDS matrix is singular")
+	}
+
+	// constants, moved up from the last partial round to the first one
+	constants := make([][]*big.Int, p.FullRounds+p.PartialRounds)
+	for r := range constants {
+		constants[r] = p.RoundConstants[r*t : (r+1)*t]
+	}
+	for i := rf + rp - 1; i >= rf; i-- {
+		inv := vecMat(constants[i+1], mInv, p.Modulus)
+		moved := make([]*big.Int, t)
+		moved[0] = new(big.Int).Set(constants[i][0])
+		for j := 1; j < t; j++ {
+			moved[j] = new(big.Int).Add(constants[i][j], inv[j])
+			moved[j].Mod(moved[j], p.Modulus)
+		}
+		constants[i] = moved
+		constants[i+1] = []*big.Int{inv[0]}
+	}
+
+	c := append([]*big.Int{}, constants[0]...)
+	for r := 1; r <= rf; r++ {
+		c = append(c, vecMat(constants[r], mInv, p.Modulus)...)
+	}
+	for r := 0; r < rp; r++ {
+		c = append(c, constants[rf+1+r][0])
+	}
+	for r := rf + rp + 1; r < p.FullRounds+p.PartialRounds; r++ {
+		c = append(c, vecMat(constants[r], mInv, p.Modulus)...)
+	}
+
+	// sparse matrices, from the last partial round to the first one
+	mMul := m
+	v := make([][]*big.Int, rp)
+	wHat := make([][]*big.Int, rp)
+	for i := rp - 1; i >= 0; i-- {
+		mHat := subMatrix(mMul, 1, 1)
+		w := make([]*big.Int, t-1)
+		for j := range w {
+			w[j] = mMul[j+1][0]
+		}
+		v[i] = mMul[0][1:]
+
+		mHatInv := matInverse(mHat, p.Modulus)
+		if mHatInv == nil {
+			return nil, fmt.Errorf("MDS matrix has a singular submatrix")
+		}
+		wHat[i] = matVec(mHatInv, w, p.Modulus)
+
+		mi := identity(t)
+		for j := 1; j < t; j++ {
+			copy(mi[j][1:], mHat[j-1])
+		}
+		mMul = matMul(m, mi, p.Modulus)
+	}
+
+	s := make([]*big.Int, 0, (2*t-1)*rp)
+	for i := 0; i < rp; i++ {
+		s = append(s, m[0][0])
+		s = append(s, wHat[i]...)
+		s = append(s, v[i]...)
+	}
+
+	res := *p
+	res.Optimized = &OptimizedConstants{C: c, S: s, M: m, P: mMul}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
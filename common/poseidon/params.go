@@ -0,0 +1,224 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const MIN_WIDTH int = 2
+const MAX_WIDTH int = 16
+
+// Params are the parameters of a Poseidon permutation over the prime field
+// of Modulus: x ↦ x^Alpha S-boxes on Width elements, FullRounds/2 full
+// rounds, PartialRounds partial rounds, then FullRounds/2 full rounds.
+//
+// The constants are either those of the textbook permutation, RoundConstants
+// and MDS, or the equivalent ones of Optimized.
+type Params struct {
+	Modulus       *big.Int
+	Width         int
+	Alpha         int
+	FullRounds    int
+	PartialRounds int
+
+	// RoundConstants holds Width constants per round, added before the
+	// S-boxes. MDS mixes the state as state[i] = Σ MDS[i][j]⋅state[j].
+	RoundConstants []*big.Int
+	MDS            [][]*big.Int
+
+	// Optimized, when set, is used instead of RoundConstants and MDS.
+	Optimized *OptimizedConstants
+}
+
+// OptimizedConstants are the constants of the optimized permutation of
+// iden3's go-iden3-crypto, whose partial rounds multiply by sparse matrices:
+// C holds Width⋅FullRounds+PartialRounds round constants, S the
+// (2⋅Width-1)⋅PartialRounds entries of the sparse matrices, M the MDS
+// matrix and P the pre-sparse one, both transposed.
+type OptimizedConstants struct {
+	C, S []*big.Int
+	M, P [][]*big.Int
+}
+
+// Validate checks that the sizes of the constants match the parameters.
+func (p *Params) Validate() error {
+	if err := p.validateShape(); err != nil {
+		return err
+	}
+
+	if o := p.Optimized; o != nil {
+		if len(o.C) != p.Width*p.FullRounds+p.PartialRounds {
+			return fmt.Errorf("expected %d optimized round constants, got %d", p.Width*p.FullRounds+p.PartialRounds, len(o.C))
+		}
+		if len(o.S) != (2*p.Width-1)*p.PartialRounds {
+			return fmt.Errorf("expected %d sparse matrix constants, got %d", (2*p.Width-1)*p.PartialRounds, len(o.S))
+		}
+		if err := p.validateMatrix("M", o.M); err != nil {
+			return err
+		}
+		return p.validateMatrix("P", o.P)
+	}
+
+	if len(p.RoundConstants) != p.Width*(p.FullRounds+p.PartialRounds) {
+		return fmt.Errorf("expected %d round constants, got %d", p.Width*(p.FullRounds+p.PartialRounds), len(p.RoundConstants))
+	}
+	return p.validateMatrix("MDS", p.MDS)
+}
+
+// validateShape checks the parameters, regardless of the constants.
+func (p *Params) validateShape() error {
+	switch {
+	case p.Modulus == nil || p.Modulus.Cmp(big.NewInt(2)) <= 0:
+		return fmt.Errorf("invalid modulus")
+	case p.Width < MIN_WIDTH || p.Width > MAX_WIDTH:
+		return fmt.Errorf("width %d is not between %d and %d", p.Width, MIN_WIDTH, MAX_WIDTH)
+	case p.Alpha < 3:
+		return fmt.Errorf("alpha %d is less than 3", p.Alpha)
+	case p.FullRounds < 2 || p.FullRounds%2 != 0:
+		return fmt.Errorf("number of full rounds %d is not a positive even number", p.FullRounds)
+	case p.PartialRounds < 0:
+		return fmt.Errorf("negative number of partial rounds %d", p.PartialRounds)
+	}
+	return nil
+}
+
+func (p *Params) validateMatrix(name string, m [][]*big.Int) error {
+	if len(m) != p.Width {
+		return fmt.Errorf("matrix %s has %d rows, expected %d", name, len(m), p.Width)
+	}
+	for i := range m {
+		if len(m[i]) != p.Width {
+			return fmt.Errorf("row %d of matrix %s has %d entries, expected %d", i, name, len(m[i]), p.Width)
+		}
+	}
+	return nil
+}
+
+// IsPermutation reports whether x ↦ x^Alpha is a permutation of the field,
+// i.e. gcd(Alpha, Modulus-1) = 1. Otherwise the S-boxes are not invertible.
+func (p *Params) IsPermutation() bool {
+	pm1 := new(big.Int).Sub(p.Modulus, big.NewInt(1))
+	return new(big.Int).GCD(nil, nil, big.NewInt(int64(p.Alpha)), pm1).Cmp(big.NewInt(1)) == 0
+}
+
+// Permute computes the permutation natively, as Chip.Poseidon does in a
+// circuit.
+func (p *Params) Permute(state []*big.Int) []*big.Int {
+	s := make([]*big.Int, p.Width)
+	for i := range s {
+		s[i] = new(big.Int).Mod(state[i], p.Modulus)
+	}
+	if p.Optimized != nil {
+		return p.permuteOptimized(s)
+	}
+
+	for r := 0; r < p.FullRounds+p.PartialRounds; r++ {
+		for i := range s {
+			s[i].Add(s[i], p.RoundConstants[r*p.Width+i])
+		}
+		if r < p.FullRounds/2 || r >= p.FullRounds/2+p.PartialRounds {
+			for i := range s {
+				s[i] = p.sbox(s[i])
+			}
+		} else {
+			s[0] = p.sbox(s[0])
+		}
+		s = p.mix(s, p.MDS, false)
+	}
+	return s
+}
+
+func (p *Params) permuteOptimized(s []*big.Int) []*big.Int {
+	o, t := p.Optimized, p.Width
+	ark := func(s []*big.Int, it int) {
+		for i := range s {
+			s[i].Add(s[i], o.C[it+i]).Mod(s[i], p.Modulus)
+		}
+	}
+	sboxes := func(s []*big.Int) {
+		for i := range s {
+			s[i] = p.sbox(s[i])
+		}
+	}
+
+	ark(s, 0)
+	for i := 0; i < p.FullRounds/2-1; i++ {
+		sboxes(s)
+		ark(s, (i+1)*t)
+		s = p.mix(s, o.M, true)
+	}
+	sboxes(s)
+	ark(s, (p.FullRounds/2)*t)
+	s = p.mix(s, o.P, true)
+
+	for i := 0; i < p.PartialRounds; i++ {
+		s[0] = p.sbox(s[0])
+		s[0].Add(s[0], o.C[(p.FullRounds/2+1)*t+i])
+
+		newState0 := new(big.Int)
+		for j := 0; j < t; j++ {
+			newState0.Add(newState0, new(big.Int).Mul(o.S[(t*2-1)*i+j], s[j]))
+		}
+		for k := 1; k < t; k++ {
+			s[k].Add(s[k], new(big.Int).Mul(s[0], o.S[(t*2-1)*i+t+k-1])).Mod(s[k], p.Modulus)
+		}
+		s[0] = newState0.Mod(newState0, p.Modulus)
+	}
+
+	for i := 0; i < p.FullRounds/2-1; i++ {
+		sboxes(s)
+		ark(s, (p.FullRounds/2+1)*t+p.PartialRounds+i*t)
+		s = p.mix(s, o.M, true)
+	}
+	sboxes(s)
+	return p.mix(s, o.M, true)
+}
+
+func (p *Params) sbox(x *big.Int) *big.Int {
+	return new(big.Int).Exp(x, big.NewInt(int64(p.Alpha)), p.Modulus)
+}
+
+// mix multiplies s by m, or by the transpose of m.
+func (p *Params) mix(s []*big.Int, m [][]*big.Int, transposed bool) []*big.Int {
+	res := make([]*big.Int, len(s))
+	for i := range res {
+		res[i] = new(big.Int)
+		for j := range s {
+			c := m[i][j]
+			if transposed {
+				c = m[j][i]
+			}
+			res[i].Add(res[i], new(big.Int).Mul(c, s[j]))
+		}
+		res[i].Mod(res[i], p.Modulus)
+	}
+	return res
+}
+
+// Hash absorbs input Width-1 elements at a time into the rate, overwriting
+// it, and permutes, like BLS12377Chip.HashNoPad without the packing. It
+// returns the first element of the state.
+func (p *Params) Hash(input []*big.Int) *big.Int {
+	state := make([]*big.Int, p.Width)
+	for i := range state {
+		state[i] = new(big.Int)
+	}
+	for i := 0; i < len(input); i += p.Width - 1 {
+		for j := i; j < len(input) && j < i+p.Width-1; j++ {
+			state[j-i+1] = input[j]
+		}
+		state = p.Permute(state)
+	}
+	return state[0]
+}
+
+// TwoToOne compresses left and right, placed in the last two elements of a
+// zero state, like BLS12377Chip.TwoToOne.
+func (p *Params) TwoToOne(left, right *big.Int) *big.Int {
+	state := make([]*big.Int, p.Width)
+	for i := range state {
+		state[i] = new(big.Int)
+	}
+	state[p.Width-2], state[p.Width-1] = left, right
+	return p.Permute(state)[0]
+}
@@ -0,0 +1,99 @@
+package poseidon
+
+import (
+	"math/big"
+)
+
+// Polynomials over the prime field of a modulus, coefficients of the lowest
+// degree first and without leading zeros, for the eigenvalues of the checks
+// of the MDS matrix.
+
+func polyTrim(a []*big.Int) []*big.Int {
+	for len(a) > 0 && a[len(a)-1].Sign() == 0 {
+		a = a[:len(a)-1]
+	}
+	return a
+}
+
+func polySub(a, b []*big.Int, modulus *big.Int) []*big.Int {
+	res := make([]*big.Int, max(len(a), len(b)))
+	for i := range res {
+		res[i] = new(big.Int)
+		if i < len(a) {
+			res[i].Add(res[i], a[i])
+		}
+		if i < len(b) {
+			res[i].Sub(res[i], b[i])
+		}
+		res[i].Mod(res[i], modulus)
+	}
+	return polyTrim(res)
+}
+
+func polyScale(a []*big.Int, c *big.Int, modulus *big.Int) []*big.Int {
+	res := make([]*big.Int, len(a))
+	for i := range res {
+		res[i] = new(big.Int).Mul(a[i], c)
+		res[i].Mod(res[i], modulus)
+	}
+	return polyTrim(res)
+}
+
+func polyMul(a, b []*big.Int, modulus *big.Int) []*big.Int {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	res := make([]*big.Int, len(a)+len(b)-1)
+	for i := range res {
+		res[i] = new(big.Int)
+	}
+	for i := range a {
+		for j := range b {
+			res[i+j].Add(res[i+j], new(big.Int).Mul(a[i], b[j]))
+		}
+	}
+	for i := range res {
+		res[i].Mod(res[i], modulus)
+	}
+	return polyTrim(res)
+}
+
+// polyMod returns the remainder of a divided by the non-zero b.
+func polyMod(a, b []*big.Int, modulus *big.Int) []*big.Int {
+	res := make([]*big.Int, len(a))
+	for i := range res {
+		res[i] = new(big.Int).Set(a[i])
+	}
+	inv := new(big.Int).ModInverse(b[len(b)-1], modulus)
+	for len(res) >= len(b) {
+		f := new(big.Int).Mul(res[len(res)-1], inv)
+		shift := len(res) - len(b)
+		for i := range b {
+			res[shift+i].Sub(res[shift+i], new(big.Int).Mul(f, b[i])).Mod(res[shift+i], modulus)
+		}
+		res = polyTrim(res[:len(res)-1])
+	}
+	return polyTrim(res)
+}
+
+// hasRoot reports whether f has a root in the field, that is whether it
+// shares a factor with x^p - x.
+func hasRoot(f []*big.Int, modulus *big.Int) bool {
+	if len(f) < 2 {
+		return false
+	}
+	x := []*big.Int{new(big.Int), big.NewInt(1)}
+	xp := []*big.Int{big.NewInt(1)}
+	for i := modulus.BitLen() - 1; i >= 0; i-- {
+		xp = polyMod(polyMul(xp, xp, modulus), f, modulus)
+		if modulus.Bit(i) == 1 {
+			xp = polyMod(polyMul(xp, x, modulus), f, modulus)
+		}
+	}
+
+	a, b := f, polySub(xp, x, modulus)
+	for len(b) > 0 {
+		a, b = b, polyMod(a, b, modulus)
+	}
+	return len(a) > 1
+}
@@ -0,0 +1,96 @@
+package poseidon
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// PRESET_SECURITY is the security level, in bits, of the presets.
+const PRESET_SECURITY int = 128
+
+// presetAlphas are the S-box exponents of the presets: the smallest of the
+// usual 5 and 17 coprime with r-1 for the scalar field of order r.
+var presetAlphas = map[ecc.ID]int{
+	ecc.BN254:     5,
+	ecc.BLS12_381: 5,
+	ecc.BLS12_377: 17,
+	ecc.BW6_761:   5,
+}
+
+// PresetAlpha returns the S-box exponent of the presets of curve.
+func PresetAlpha(curve ecc.ID) (int, error) {
+	alpha, ok := presetAlphas[curve]
+	if !ok {
+		return 0, fmt.Errorf("no poseidon preset for %s", curve)
+	}
+	return alpha, nil
+}
+
+var (
+	presetsLock sync.Mutex
+	presets     = map[presetKey]*Params{}
+)
+
+type presetKey struct {
+	curve ecc.ID
+	width int
+}
+
+// Preset returns the parameters of the reference implementation over the
+// scalar field of curve, for the given width: the round numbers of
+// RoundNumbers for PRESET_SECURITY bits and the constants of Generate. They
+// are generated on first use and shared, so they must not be modified.
+//
+// The BLS12-377 presets use x^17 S-boxes, unlike BLS12377ChipParams.
+func Preset(curve ecc.ID, width int) (*Params, error) {
+	alpha, err := PresetAlpha(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	presetsLock.Lock()
+	defer presetsLock.Unlock()
+
+	key := presetKey{curve, width}
+	if p, ok := presets[key]; ok {
+		return p, nil
+	}
+	if width < MIN_WIDTH || width > MAX_WIDTH {
+		return nil, fmt.Errorf("width %d is not between %d and %d", width, MIN_WIDTH, MAX_WIDTH)
+	}
+
+	modulus := curve.ScalarField()
+	fullRounds, partialRounds := RoundNumbers(modulus, width, alpha, PRESET_SECURITY)
+	p, err := Generate(modulus, width, alpha, fullRounds, partialRounds)
+	if err != nil {
+		return nil, err
+	}
+	presets[key] = p
+	return p, nil
+}
+
+// BLS12377ChipParams returns the parameters of BLS12377Chip: a width of 4,
+// x^5 S-boxes, 8 full and 56 partial rounds, with the optimized constants
+// of bls12377_constants.go.
+//
+// Note that 5 divides r-1 for the BLS12-377 scalar field of order r, so
+// x^5 is not a permutation of the field and the S-boxes are not invertible.
+// The parameters are kept as they are for compatibility with the existing
+// circuits; Preset(ecc.BLS12_377, width) uses x^17.
+func BLS12377ChipParams() *Params {
+	return &Params{
+		Modulus:       ecc.BLS12_377.ScalarField(),
+		Width:         BLS12377_SPONGE_WIDTH,
+		Alpha:         5,
+		FullRounds:    BLS12377_FULL_ROUNDS,
+		PartialRounds: BLS12377_PARTIAL_ROUNDS,
+		Optimized: &OptimizedConstants{
+			C: cConstantsBLS12377,
+			S: sConstantsBLS12377,
+			M: mMatrixBLS12377,
+			P: pMatrixBLS12377,
+		},
+	}
+}
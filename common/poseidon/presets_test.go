@@ -0,0 +1,125 @@
+package poseidon_test
+
+import (
+	"gnark-circuit-gen/common/poseidon"
+	"gnark-circuit-gen/pkg/curves"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// presetCircuit asserts that the generic chip of params maps State, Input,
+// Left and Right to the outputs computed natively.
+type presetCircuit struct {
+	State       []frontend.Variable
+	Input       []frontend.Variable
+	Left, Right frontend.Variable
+
+	Permutation []frontend.Variable `gnark:",public"`
+	Hash        frontend.Variable   `gnark:",public"`
+	TwoToOne    frontend.Variable   `gnark:",public"`
+
+	params *poseidon.Params `gnark:"-"`
+}
+
+func (c *presetCircuit) Define(api frontend.API) error {
+	chip, err := poseidon.NewChip(api, c.params)
+	if err != nil {
+		return err
+	}
+
+	permutation := chip.Poseidon(c.State)
+	for i := range permutation {
+		api.AssertIsEqual(permutation[i], c.Permutation[i])
+	}
+	api.AssertIsEqual(chip.Hash(c.Input), c.Hash)
+	api.AssertIsEqual(chip.TwoToOne(c.Left, c.Right), c.TwoToOne)
+	return nil
+}
+
+// circomlibPoseidon12 is Poseidon([1, 2]) of circomlib, the first element of
+// the permutation of [0, 1, 2] with the BN254 constants of the reference for
+// a width of 3 with 8 full and 57 partial rounds.
+const circomlibPoseidon12 = "7853200120776062878684798364095072458815029376092732009249414926327459813530"
+
+// TestGenerateCircomlib checks the constant generation against circomlib.
+func TestGenerateCircomlib(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	params, err := poseidon.Generate(ecc.BN254.ScalarField(), 3, 5, 8, 57)
+	assert.NoError(err)
+	hash := params.Permute([]*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2)})[0]
+	assert.Equal(circomlibPoseidon12, hash.String())
+}
+
+// TestPresets checks that the generic chip agrees with the native
+// permutation of the presets of every supported curve and width on random
+// inputs, in their textbook and optimized forms.
+func TestPresets(t *testing.T) {
+	rng := newRand()
+	for _, curve := range curves.Supported {
+		for width := poseidon.MIN_WIDTH; width <= poseidon.MAX_WIDTH; width++ {
+			assert := test.NewAssert(t)
+
+			params, err := poseidon.Preset(curve, width)
+			assert.NoError(err)
+			checkParams(assert, rng, curve, params, "%s preset of width %d", curves.Name(curve), width)
+
+			optimized, err := params.Optimize()
+			assert.NoError(err)
+			checkParams(assert, rng, curve, optimized, "optimized %s preset of width %d", curves.Name(curve), width)
+		}
+	}
+}
+
+// TestBLS12377ChipParams checks that the generic chip of
+// BLS12377ChipParams agrees with its native permutation, and that it is the
+// one of BLS12377Native.
+func TestBLS12377ChipParams(t *testing.T) {
+	assert := test.NewAssert(t)
+	rng := newRand()
+
+	params := poseidon.BLS12377ChipParams()
+	checkParams(assert, rng, ecc.BLS12_377, params, "BLS12377Chip parameters")
+
+	state := randomElements(rng, params.Modulus, params.Width)
+	var native poseidon.BLS12377NativeState
+	copy(native[:], poseidon.BLS12377Elements(state...))
+	native = poseidon.NewBLS12377Native().Poseidon(native)
+	for i, v := range params.Permute(state) {
+		var e fr.Element
+		e.SetBigInt(v)
+		assert.True(e.Equal(&native[i]), "permutation differs from BLS12377Native")
+	}
+}
+
+// checkParams solves the generic chip of params for curve on random inputs
+// whose outputs are computed with params natively.
+func checkParams(assert *test.Assert, rng *rand.Rand, curve ecc.ID, params *poseidon.Params, msgAndArgs ...any) {
+	state := randomElements(rng, params.Modulus, params.Width)
+	// more than two blocks, the last one partial
+	input := randomElements(rng, params.Modulus, 2*params.Width+1)
+	pair := randomElements(rng, params.Modulus, 2)
+
+	assignment := &presetCircuit{
+		State:       variables(state),
+		Input:       variables(input),
+		Left:        pair[0],
+		Right:       pair[1],
+		Permutation: variables(params.Permute(state)),
+		Hash:        params.Hash(input),
+		TwoToOne:    params.TwoToOne(pair[0], pair[1]),
+	}
+	circuit := &presetCircuit{
+		State:       make([]frontend.Variable, len(state)),
+		Input:       make([]frontend.Variable, len(input)),
+		Permutation: make([]frontend.Variable, params.Width),
+		params:      params,
+	}
+	assert.NoError(test.IsSolved(circuit, assignment, curve.ScalarField()), msgAndArgs...)
+}
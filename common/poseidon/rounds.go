@@ -0,0 +1,77 @@
+package poseidon
+
+import (
+	"math"
+	"math/big"
+)
+
+// RoundNumbers returns the numbers of full and partial rounds of the
+// reference implementation for the given security level in bits: the
+// cheapest ones, in S-boxes, resisting the statistical, interpolation and
+// Gröbner basis attacks of the Poseidon paper and the one of
+// https://eprint.iacr.org/2023/537, plus the security margin of 2 full
+// rounds and 7.5% partial rounds.
+//
+// They can differ from the numbers of older tables, e.g. circomlib's, which
+// predate the latter attack and revisions of the bounds.
+func RoundNumbers(modulus *big.Int, width, alpha, security int) (fullRounds, partialRounds int) {
+	f, _ := new(big.Float).SetInt(modulus).Float64()
+	logP := math.Log2(f)
+
+	minCost, maxCostFullRounds := math.Inf(1), 0
+	for rp := 1; rp < 500; rp++ {
+		// the reference adds the margin to its loop variable of partial
+		// rounds, which carries over to the next numbers of full rounds
+		rpm := rp
+		for rf := 4; rf < 100; rf += 2 {
+			if !secure(modulus.BitLen(), logP, width, rf, rpm, alpha, security) {
+				continue
+			}
+			rfm := rf + 2
+			rpm = int(math.Ceil(float64(rpm) * 1.075))
+
+			cost := float64(rfm*width + rpm)
+			if cost < minCost || (cost == minCost && rfm < maxCostFullRounds) {
+				fullRounds, partialRounds = rfm, rpm
+				minCost, maxCostFullRounds = cost, rfm
+			}
+		}
+	}
+	return
+}
+
+// secure reports whether rf full and rp partial rounds resist the known
+// attacks in a field of fieldSize bits and order 2^logP, as
+// sat_inequiv_alpha of the reference.
+func secure(fieldSize int, logP float64, t, rf, rp, alpha, security int) bool {
+	n := float64(fieldSize)
+	logAlpha := func(x float64) float64 { return math.Log(x) / math.Log(float64(alpha)) }
+	m, tf := float64(security), float64(t)
+	rpf := float64(rp)
+
+	rf1 := 10.0 // statistical
+	if m <= math.Floor(logP-float64(alpha-1)/2)*(tf+1) {
+		rf1 = 6
+	}
+	rf2 := 1 + math.Ceil(logAlpha(2)*math.Min(m, n)) + math.Ceil(logAlpha(tf)) - rpf // interpolation
+	rf3 := logAlpha(2)*math.Min(m, logP) - rpf                                       // Gröbner 1
+	rf4 := tf - 1 + logAlpha(2)*math.Min(m/(tf+1), logP/2) - rpf                     // Gröbner 2
+	rf5 := (tf - 2 + m/(2*math.Log2(float64(alpha))) - rpf) / (tf - 1)               // Gröbner 3
+	rfMax := math.Max(math.Max(math.Ceil(rf1), math.Ceil(rf2)), math.Max(math.Max(math.Ceil(rf3), math.Ceil(rf4)), math.Ceil(rf5)))
+
+	// https://eprint.iacr.org/2023/537
+	r := math.Floor(tf / 3)
+	over := float64(rf-1)*tf + rpf + r + r*float64(rf/2) + rpf + float64(alpha)
+	under := r*float64(rf/2) + rpf + float64(alpha)
+	costGB4 := math.Ceil(2 * log2Binomial(over, under))
+
+	return float64(rf) >= rfMax && costGB4 >= m
+}
+
+// log2Binomial returns log2 of the binomial coefficient (n k).
+func log2Binomial(n, k float64) float64 {
+	ln, _ := math.Lgamma(n + 1)
+	lk, _ := math.Lgamma(k + 1)
+	lnk, _ := math.Lgamma(n - k + 1)
+	return (ln - lk - lnk) / math.Ln2
+}
@@ -0,0 +1,82 @@
+package poseidon
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// BLS12377_SQUEEZE_BITS is the number of low bits SqueezeBits takes from
+// each squeezed element. The low 128 bits of a uniform element of the
+// BLS12-377 scalar field are within 2^-124 of uniform.
+const BLS12377_SQUEEZE_BITS int = 128
+
+// BLS12377Sponge is a duplex sponge over BLS12377Chip.Poseidon, for
+// Fiat-Shamir transcripts. Absorbed elements are added to the rate,
+// state[1:], and squeezed elements are read from it; the state is permuted
+// whenever the rate is used up and when switching from absorbing to
+// squeezing. Absorb and squeeze calls can be interleaved freely.
+// BLS12377NativeSponge computes the same challenges outside the circuit.
+type BLS12377Sponge struct {
+	chip  *BLS12377Chip
+	state BLS12377State
+	// squeezing is set after a squeeze until the next absorb
+	squeezing bool
+	// index is the next position in the rate to absorb into or squeeze from
+	index int
+}
+
+// NewBLS12377Sponge returns a sponge over chip whose capacity holds domain,
+// one of the DOMAIN_* tags or any other value separating the transcripts
+// of different protocols.
+func NewBLS12377Sponge(chip *BLS12377Chip, domain uint64) *BLS12377Sponge {
+	s := &BLS12377Sponge{chip: chip}
+	s.state[0] = new(big.Int).SetUint64(domain)
+	for i := 1; i < BLS12377_SPONGE_WIDTH; i++ {
+		s.state[i] = frontend.Variable(0)
+	}
+	return s
+}
+
+// Absorb adds elements to the transcript.
+func (s *BLS12377Sponge) Absorb(elements ...frontend.Variable) {
+	if s.squeezing {
+		s.squeezing, s.index = false, 0
+	}
+	for _, e := range elements {
+		if s.index == BLS12377_SPONGE_RATE {
+			s.state = s.chip.Poseidon(s.state)
+			s.index = 0
+		}
+		s.state[s.index+1] = s.chip.api.Add(s.state[s.index+1], e)
+		s.index++
+	}
+}
+
+// Squeeze returns the next n field elements of the sponge.
+func (s *BLS12377Sponge) Squeeze(n int) []frontend.Variable {
+	if !s.squeezing {
+		s.state = s.chip.Poseidon(s.state)
+		s.squeezing, s.index = true, 0
+	}
+	res := make([]frontend.Variable, n)
+	for i := range res {
+		if s.index == BLS12377_SPONGE_RATE {
+			s.state = s.chip.Poseidon(s.state)
+			s.index = 0
+		}
+		res[i] = s.state[s.index+1]
+		s.index++
+	}
+	return res
+}
+
+// SqueezeBits returns n bits, least significant first, taken
+// BLS12377_SQUEEZE_BITS at a time from as many newly squeezed elements.
+func (s *BLS12377Sponge) SqueezeBits(n int) []frontend.Variable {
+	bits := []frontend.Variable{}
+	for _, e := range s.Squeeze((n + BLS12377_SQUEEZE_BITS - 1) / BLS12377_SQUEEZE_BITS) {
+		bits = append(bits, s.chip.api.ToBinary(e)[:BLS12377_SQUEEZE_BITS]...)
+	}
+	return bits[:n]
+}
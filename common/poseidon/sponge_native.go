@@ -0,0 +1,65 @@
+package poseidon
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+)
+
+// BLS12377NativeSponge mirrors BLS12377Sponge over BLS12377Native.
+type BLS12377NativeSponge struct {
+	native    *BLS12377Native
+	state     BLS12377NativeState
+	squeezing bool
+	index     int
+}
+
+func NewBLS12377NativeSponge(native *BLS12377Native, domain uint64) *BLS12377NativeSponge {
+	s := &BLS12377NativeSponge{native: native}
+	s.state[0].SetUint64(domain)
+	return s
+}
+
+func (s *BLS12377NativeSponge) Absorb(elements ...fr.Element) {
+	if s.squeezing {
+		s.squeezing, s.index = false, 0
+	}
+	for _, e := range elements {
+		if s.index == BLS12377_SPONGE_RATE {
+			s.state = s.native.Poseidon(s.state)
+			s.index = 0
+		}
+		s.state[s.index+1].Add(&s.state[s.index+1], &e)
+		s.index++
+	}
+}
+
+func (s *BLS12377NativeSponge) Squeeze(n int) []fr.Element {
+	if !s.squeezing {
+		s.state = s.native.Poseidon(s.state)
+		s.squeezing, s.index = true, 0
+	}
+	res := make([]fr.Element, n)
+	for i := range res {
+		if s.index == BLS12377_SPONGE_RATE {
+			s.state = s.native.Poseidon(s.state)
+			s.index = 0
+		}
+		res[i] = s.state[s.index+1]
+		s.index++
+	}
+	return res
+}
+
+// SqueezeBits returns n bits as 0 or 1, least significant first.
+func (s *BLS12377NativeSponge) SqueezeBits(n int) []uint {
+	bits := []uint{}
+	for _, e := range s.Squeeze((n + BLS12377_SQUEEZE_BITS - 1) / BLS12377_SQUEEZE_BITS) {
+		var v big.Int
+		e.BigInt(&v)
+		for i := 0; i < BLS12377_SQUEEZE_BITS; i++ {
+			bits = append(bits, v.Bit(i))
+		}
+	}
+	return bits[:n]
+}
@@ -0,0 +1,109 @@
+package poseidon_test
+
+import (
+	"fmt"
+	"gnark-circuit-gen/common/poseidon"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// spongeOp is a call on a sponge: absorbing or squeezing n elements, or
+// squeezing n bits.
+type spongeOp struct {
+	kind string
+	n    int
+}
+
+// spongeTranscript interleaves absorbs and squeezes so that they fill the
+// rate exactly, cross its end, follow one another and squeeze bits from
+// one and several elements.
+var spongeTranscript = []spongeOp{
+	{"absorb", poseidon.BLS12377_SPONGE_RATE},
+	{"squeeze", 1},
+	{"absorb", 1},
+	{"squeeze", 5},
+	{"squeeze", 2},
+	{"squeeze-bits", 7},
+	{"absorb", 8},
+	{"absorb", 2},
+	{"squeeze-bits", poseidon.BLS12377_SQUEEZE_BITS + 3},
+	{"squeeze", poseidon.BLS12377_SPONGE_RATE},
+}
+
+// spongeCircuit replays spongeTranscript on BLS12377Sponge, absorbing
+// Input in order and asserting that the squeezed elements and bits are the
+// ones computed natively.
+type spongeCircuit struct {
+	Input []frontend.Variable
+
+	Elements []frontend.Variable `gnark:",public"`
+	Bits     []frontend.Variable `gnark:",public"`
+}
+
+func (c *spongeCircuit) Define(api frontend.API) error {
+	sponge := poseidon.NewBLS12377Sponge(poseidon.NewBLS12377Chip(api), poseidon.DOMAIN_TRANSCRIPT)
+
+	var elements, bits []frontend.Variable
+	input := c.Input
+	for _, op := range spongeTranscript {
+		switch op.kind {
+		case "absorb":
+			sponge.Absorb(input[:op.n]...)
+			input = input[op.n:]
+		case "squeeze":
+			elements = append(elements, sponge.Squeeze(op.n)...)
+		case "squeeze-bits":
+			bits = append(bits, sponge.SqueezeBits(op.n)...)
+		}
+	}
+
+	if len(elements) != len(c.Elements) || len(bits) != len(c.Bits) {
+		return fmt.Errorf("squeezed %d elements and %d bits, natively %d and %d", len(elements), len(bits), len(c.Elements), len(c.Bits))
+	}
+	for i := range elements {
+		api.AssertIsEqual(elements[i], c.Elements[i])
+	}
+	for i := range bits {
+		api.AssertIsEqual(bits[i], c.Bits[i])
+	}
+	return nil
+}
+
+// TestBLS12377Sponge checks that BLS12377Sponge and BLS12377NativeSponge
+// squeeze the same elements and bits from random inputs over
+// spongeTranscript.
+func TestBLS12377Sponge(t *testing.T) {
+	rng := newRand()
+	sponge := poseidon.NewBLS12377NativeSponge(poseidon.NewBLS12377Native(), poseidon.DOMAIN_TRANSCRIPT)
+
+	var input, squeezed []fr.Element
+	var bits []uint
+	for _, op := range spongeTranscript {
+		switch op.kind {
+		case "absorb":
+			absorbed := poseidon.BLS12377Elements(randomElements(rng, fr.Modulus(), op.n)...)
+			sponge.Absorb(absorbed...)
+			input = append(input, absorbed...)
+		case "squeeze":
+			squeezed = append(squeezed, sponge.Squeeze(op.n)...)
+		case "squeeze-bits":
+			bits = append(bits, sponge.SqueezeBits(op.n)...)
+		}
+	}
+
+	assignment := &spongeCircuit{
+		Input:    variables(input),
+		Elements: variables(squeezed),
+		Bits:     variables(bits),
+	}
+	circuit := &spongeCircuit{
+		Input:    make([]frontend.Variable, len(input)),
+		Elements: make([]frontend.Variable, len(squeezed)),
+		Bits:     make([]frontend.Variable, len(bits)),
+	}
+	test.NewAssert(t).NoError(test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()))
+}
@@ -0,0 +1,117 @@
+#!/usr/bin/env python3
+"""Known answers of the BLS12-377 Poseidon permutation, independently of Go.
+
+A Python 3 port, without Sage, of generate_parameters_grain.sage and of the
+textbook permutation of poseidonperm_x5_255_3.sage, the reference scripts of
+the Poseidon paper (https://extgit.iaik.tugraz.at/krypto/hadeshash), for the
+parameters of poseidon.BLS12377ChipParams: a width of 4, x^5 S-boxes, 8 full
+and 56 partial rounds. The security checks of the MDS matrix (algorithms 1
+to 3 of the reference) are not ported: the first matrix drawn is used.
+
+    python3 testdata/reference.py > testdata/bls12377_reference.json
+
+writes the vectors checked by TestBLS12377KnownAnswers next to those of
+bls12377.json, which are computed by the Go code of the package.
+"""
+
+import json
+
+P = 0x12AB655E9A2CA55660B44D1E5C37B00159AA76FED00000010A11800000000001
+N = P.bit_length()
+T = 4
+ALPHA = 5
+R_F = 8
+R_P = 56
+
+INPUTS = [
+    [0, 0, 0, 0],
+    [0, 1, 2, 3],
+    [P - 1, P - 2, P - 3, P - 4],
+]
+
+
+def grain(field, sbox, n, t, r_f, r_p):
+    """Yields the bits of the Grain LFSR, self-shrunk as in the reference."""
+    state = []
+    for value, size in ((field, 2), (sbox, 4), (n, 12), (t, 12), (r_f, 10), (r_p, 10)):
+        state += [int(b) for b in bin(value)[2:].zfill(size)]
+    state += [1] * 30
+
+    def step():
+        bit = state[62] ^ state[51] ^ state[38] ^ state[23] ^ state[13] ^ state[0]
+        state.pop(0)
+        state.append(bit)
+        return bit
+
+    for _ in range(160):
+        step()
+    while True:
+        bit = step()
+        while bit == 0:
+            step()
+            bit = step()
+        yield step()
+
+
+def random_bits(bits, n):
+    value = 0
+    for _ in range(n):
+        value = (value << 1) | next(bits)
+    return value
+
+
+def parameters():
+    """Returns the round constants and the MDS matrix, drawn in this order."""
+    bits = grain(1, 0, N, T, R_F, R_P)
+
+    constants = []
+    for _ in range((R_F + R_P) * T):
+        c = random_bits(bits, N)
+        while c >= P:
+            c = random_bits(bits, N)
+        constants.append(c)
+
+    while True:
+        values = [random_bits(bits, N) % P for _ in range(2 * T)]
+        while len(set(values)) != 2 * T:
+            values = [random_bits(bits, N) % P for _ in range(2 * T)]
+        xs, ys = values[:T], values[T:]
+        if all((x + y) % P != 0 for x in xs for y in ys):
+            break
+    mds = [[pow(x + y, -1, P) for y in ys] for x in xs]
+    return constants, mds
+
+
+def permutation(state, constants, mds):
+    state = list(state)
+    counter = 0
+    for r in range(R_F + R_P):
+        for i in range(T):
+            state[i] = (state[i] + constants[counter]) % P
+            counter += 1
+        full = r < R_F // 2 or r >= R_F // 2 + R_P
+        for i in range(T if full else 1):
+            state[i] = pow(state[i], ALPHA, P)
+        state = [sum(m * s for m, s in zip(row, state)) % P for row in mds]
+    return state
+
+
+def main():
+    constants, mds = parameters()
+    vectors = [
+        {
+            "input": [str(x) for x in state],
+            "output": [str(x) for x in permutation(state, constants, mds)],
+        }
+        for state in INPUTS
+    ]
+    source = (
+        "Computed by testdata/reference.py, a Python port of the reference "
+        "Sage scripts of the Poseidon paper written independently of the Go "
+        "code of this package."
+    )
+    print(json.dumps({"source": source, "permutation": vectors}, indent=2))
+
+
+if __name__ == "__main__":
+    main()
@@ -1,17 +1,350 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"gnark-circuit-gen/common/poseidon"
 	"gnark-circuit-gen/pkg/circuit_gen"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/poseidonparams"
+	"gnark-circuit-gen/pkg/stats"
+	"gnark-circuit-gen/pkg/verify"
+	"math/big"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/logger"
 	"github.com/urfave/cli"
 )
 
-func handler(test_case circuit_gen.TestCase) func(ctx *cli.Context) error {
+var outFlag = cli.StringFlag{
+	Name:  "out",
+	Usage: "root directory of the artifacts, written to <out>/<case>/<run-id>/",
+	Value: circuit_gen.DefaultOutDir,
+}
+
+var seedFlag = cli.Uint64Flag{
+	Name:  "seed",
+	Usage: "seed of the random assignment, a fresh one is drawn and recorded in run.json when omitted",
+}
+
+var curveFlag = cli.StringFlag{
+	Name:  "curve",
+	Usage: "curve whose scalar field the circuit is compiled for, one of " + strings.Join(curves.Names(), ", "),
+	Value: curves.Name(curves.Default),
+}
+
+var backendFlag = cli.StringFlag{
+	Name:  "backend",
+	Usage: "constraint system to compile to, r1cs or scs (PLONK)",
+	Value: string(circuit_gen.R1CS),
+}
+
+var profileFlag = cli.BoolFlag{
+	Name:  "profile",
+	Usage: "attribute constraints and lookup entries to the regions of the circuit, written to profile.pprof and regions.txt",
+}
+
+var witnessesFlag = cli.IntFlag{
+	Name:  "witnesses",
+	Usage: "number of random assignments solved against the compiled constraint system",
+	Value: 1,
+}
+
+var invalidFlag = cli.BoolFlag{
+	Name:  "invalid",
+	Usage: "also derive deliberately invalid assignments, written to invalid_NNNN.cbor and described in invalid.json (r1cs only)",
+}
+
+var circomFlag = cli.BoolFlag{
+	Name:  "circom",
+	Usage: "also write circuit.r1cs, circuit.sym and the assignments as .wtns, in the formats read by circom and snarkjs (r1cs only)",
+}
+
+var formatFlag = cli.StringFlag{
+	Name:  "format",
+	Usage: fmt.Sprintf("%s, or %s to also write a JSON rendering of every CBOR artifact", circuit_gen.CBOR, circuit_gen.JSON),
+	Value: string(circuit_gen.CBOR),
+}
+
+var hexFlag = cli.BoolFlag{
+	Name:  "hex",
+	Usage: "print field elements in hexadecimal in JSON renderings",
+}
+
+// generationFlags are the flags of the commands generating artifacts.
+var generationFlags = []cli.Flag{outFlag, seedFlag, curveFlag, backendFlag, profileFlag, witnessesFlag, invalidFlag, circomFlag, formatFlag, hexFlag}
+
+func options(ctx *cli.Context) (circuit_gen.Options, error) {
+	curve, err := curves.Parse(ctx.String(curveFlag.Name))
+	if err != nil {
+		return circuit_gen.Options{}, err
+	}
+	backend, err := circuit_gen.ParseBackend(ctx.String(backendFlag.Name))
+	if err != nil {
+		return circuit_gen.Options{}, err
+	}
+	format, err := circuit_gen.ParseFormat(ctx.String(formatFlag.Name))
+	if err != nil {
+		return circuit_gen.Options{}, err
+	}
+	if ctx.Int(witnessesFlag.Name) < 1 {
+		return circuit_gen.Options{}, fmt.Errorf("--%s must be at least 1", witnessesFlag.Name)
+	}
+
+	opts := circuit_gen.Options{
+		OutDir:    ctx.String(outFlag.Name),
+		Curve:     curve,
+		Backend:   backend,
+		Profile:   ctx.Bool(profileFlag.Name),
+		Witnesses: ctx.Int(witnessesFlag.Name),
+		Invalid:   ctx.Bool(invalidFlag.Name),
+		Circom:    ctx.Bool(circomFlag.Name),
+		Format:    format,
+		Hex:       ctx.Bool(hexFlag.Name),
+	}
+	if ctx.IsSet(seedFlag.Name) {
+		seed := ctx.Uint64(seedFlag.Name)
+		opts.Seed = &seed
+	}
+	return opts, nil
+}
+
+func handler(testCase registry.TestCase) func(ctx *cli.Context) error {
 	return func(ctx *cli.Context) error {
-		return circuit_gen.Run(test_case)
+		args := testCase.DefaultArgs()
+		for _, param := range testCase.Params {
+			args.Params[param.Name] = ctx.Int(param.Name)
+		}
+		opts, err := options(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = circuit_gen.Run(testCase.Name, args, opts)
+		return err
+	}
+}
+
+func genSpecHandler(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected exactly one spec file, got %d arguments", ctx.NArg())
+	}
+	opts, err := options(ctx)
+	if err != nil {
+		return err
 	}
+	_, err = circuit_gen.RunSpec(ctx.Args().First(), opts)
+	return err
+}
+
+func poseidonParamsHandler(ctx *cli.Context) error {
+	if ctx.String("out") == "" && ctx.String("verify") == "" {
+		// keep the Go source on stdout clean
+		logger.Disable()
+	}
+	log := logger.Logger().With().Logger()
+
+	curve, err := curves.Parse(ctx.String(curveFlag.Name))
+	if err != nil {
+		return err
+	}
+	modulus := curve.ScalarField()
+	name := strings.ToUpper(strings.ReplaceAll(curve.String(), "_", ""))
+	if ctx.IsSet("modulus") {
+		var ok bool
+		if modulus, ok = new(big.Int).SetString(ctx.String("modulus"), 0); !ok {
+			return fmt.Errorf("invalid modulus %q", ctx.String("modulus"))
+		}
+		if !ctx.IsSet("name") {
+			return fmt.Errorf("--name is required with --modulus")
+		}
+	}
+	if ctx.IsSet("name") {
+		name = ctx.String("name")
+	}
+
+	alpha := ctx.Int("alpha")
+	if !ctx.IsSet("alpha") {
+		if ctx.IsSet("modulus") {
+			return fmt.Errorf("--alpha is required with --modulus")
+		}
+		if alpha, err = poseidon.PresetAlpha(curve); err != nil {
+			return err
+		}
+	}
+	width, security := ctx.Int("width"), ctx.Int("security")
+	fullRounds, partialRounds := ctx.Int("full-rounds"), ctx.Int("partial-rounds")
+	if fullRounds == 0 || partialRounds == 0 {
+		rf, rp := poseidon.RoundNumbers(modulus, width, alpha, security)
+		if fullRounds == 0 {
+			fullRounds = rf
+		}
+		if partialRounds == 0 {
+			partialRounds = rp
+		}
+	}
+	log.Info().Msgf("width %d, x^%d S-boxes, %d full and %d partial rounds", width, alpha, fullRounds, partialRounds)
+
+	params, err := poseidon.Generate(modulus, width, alpha, fullRounds, partialRounds)
+	if err != nil {
+		return err
+	}
+	if !params.IsPermutation() {
+		log.Warn().Msgf("x^%d is not a permutation of the field, the S-boxes are not invertible", alpha)
+	}
+	if params, err = params.Optimize(); err != nil {
+		return err
+	}
+
+	pkg := ctx.String("package")
+	if path := ctx.String("verify"); path != "" {
+		if err := poseidonparams.Verify(path, params, pkg, name); err != nil {
+			return err
+		}
+		log.Info().Msgf("%s matches the generated parameters", path)
+		return nil
+	}
+
+	out := ctx.String("out")
+	if out == "" {
+		return poseidonparams.WriteGo(os.Stdout, params, pkg, name)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := poseidonparams.WriteGo(f, params, pkg, name); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// runCurve returns the curve the artifacts in dir were generated for: the
+// --curve flag when set, the curve recorded in run.json otherwise.
+func runCurve(ctx *cli.Context, dir string) (ecc.ID, error) {
+	if !ctx.IsSet(curveFlag.Name) {
+		if info, err := circuit_gen.ReadRunInfo(dir); err == nil {
+			return curves.Parse(info.Curve)
+		}
+	}
+	return curves.Parse(ctx.String(curveFlag.Name))
+}
+
+func verifyHandler(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected exactly one run directory, got %d arguments", ctx.NArg())
+	}
+	dir := ctx.Args().First()
+
+	curve, err := runCurve(ctx, dir)
+	if err != nil {
+		return err
+	}
+	return verify.Dir(dir, curve.ScalarField())
+}
+
+func statsHandler(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected exactly one run directory, got %d arguments", ctx.NArg())
+	}
+
+	report, err := stats.FromDir(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	data, err := report.JSON()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func proveHandler(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected exactly one run directory, got %d arguments", ctx.NArg())
+	}
+
+	report, err := circuit_gen.Prove(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func dumpHandler(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected exactly one CBOR file, got %d arguments", ctx.NArg())
+	}
+	path := ctx.Args().First()
+	// keep stdout parseable, replaying the circuit logs
+	logger.Disable()
+
+	curve, err := runCurve(ctx, filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	data, err := circuit_gen.Dump(path, curve, ctx.Bool(hexFlag.Name))
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func genAllHandler(ctx *cli.Context) error {
+	opts, err := options(ctx)
+	if err != nil {
+		return err
+	}
+
+	results, err := circuit_gen.RunAll(opts, ctx.Int("jobs"))
+	if err != nil {
+		return err
+	}
+	fmt.Print(circuit_gen.Summary(results))
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test cases failed", failed, len(results))
+	}
+	return nil
+}
+
+func testCaseCommands() []cli.Command {
+	commands := []cli.Command{}
+	for _, testCase := range registry.List() {
+		flags := append([]cli.Flag{}, generationFlags...)
+		for _, param := range testCase.Params {
+			flags = append(flags, cli.IntFlag{
+				Name:  param.Name,
+				Usage: param.Usage,
+				Value: param.Default,
+			})
+		}
+		commands = append(commands, cli.Command{
+			Name:   testCase.Name,
+			Usage:  testCase.Description,
+			Flags:  flags,
+			Action: handler(testCase),
+		})
+	}
+	return commands
 }
 
 func main() {
@@ -22,26 +355,103 @@ func main() {
 	cliApp.Usage = "cli to generate circuit required by testing"
 	cliApp.Version = "0.0.1"
 
-	cliApp.Commands = []cli.Command{
+	cliApp.Commands = append(testCaseCommands(),
 		cli.Command{
-			Name:   "test1",
-			Usage:  "generate for test 1",
-			Flags:  []cli.Flag{},
-			Action: handler(circuit_gen.Test1),
+			Name:  "gen-all",
+			Usage: "generate every test case with its default parameters",
+			Flags: append([]cli.Flag{
+				cli.IntFlag{
+					Name:  "jobs",
+					Usage: "number of test cases generated concurrently",
+					Value: runtime.NumCPU(),
+				},
+			}, generationFlags...),
+			Action: genAllHandler,
 		},
 		cli.Command{
-			Name:   "test2",
-			Usage:  "generate for test 2",
-			Flags:  []cli.Flag{},
-			Action: handler(circuit_gen.Test2),
+			Name:      "gen-spec",
+			Usage:     "generate the test case described by a YAML or JSON spec file",
+			ArgsUsage: "<file>",
+			Flags:     generationFlags,
+			Action:    genSpecHandler,
 		},
 		cli.Command{
-			Name:   "test3",
-			Usage:  "generate for test 3",
-			Flags:  []cli.Flag{},
-			Action: handler(circuit_gen.Test3),
+			Name:      "verify",
+			Usage:     "check the exported constraint system, assignment and lookup of a run",
+			ArgsUsage: "<run-dir>",
+			Flags:     []cli.Flag{curveFlag},
+			Action:    verifyHandler,
 		},
-	}
+		cli.Command{
+			Name:      "stats",
+			Usage:     "print the statistics report of the artifacts of a run",
+			ArgsUsage: "<run-dir>",
+			Action:    statsHandler,
+		},
+		cli.Command{
+			Name:      "dump",
+			Usage:     "print a CBOR artifact of a run as JSON, with named wires",
+			ArgsUsage: "<file.cbor>",
+			Flags:     []cli.Flag{curveFlag, hexFlag},
+			Action:    dumpHandler,
+		},
+		cli.Command{
+			Name:  "poseidon-params",
+			Usage: "generate the optimized poseidon constants of the reference implementation as Go source, or verify a file of them",
+			Flags: []cli.Flag{curveFlag,
+				cli.StringFlag{
+					Name:  "modulus",
+					Usage: "prime field the parameters are generated for, instead of the scalar field of --curve",
+				},
+				cli.IntFlag{
+					Name:  "width",
+					Usage: "number of field elements of the state",
+					Value: 4,
+				},
+				cli.IntFlag{
+					Name:  "alpha",
+					Usage: "exponent of the S-boxes, the one of the presets of --curve when omitted",
+				},
+				cli.IntFlag{
+					Name:  "security",
+					Usage: "security level in bits the round numbers are computed for",
+					Value: poseidon.PRESET_SECURITY,
+				},
+				cli.IntFlag{
+					Name:  "full-rounds",
+					Usage: "number of full rounds, computed for --security when 0",
+				},
+				cli.IntFlag{
+					Name:  "partial-rounds",
+					Usage: "number of partial rounds, computed for --security when 0",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "suffix of the declared variables and prefix of the constants, e.g. BLS12377 (default from --curve)",
+				},
+				cli.StringFlag{
+					Name:  "package",
+					Usage: "package of the Go source",
+					Value: "poseidon",
+				},
+				cli.StringFlag{
+					Name:  "out",
+					Usage: "file the Go source is written to, stdout when omitted",
+				},
+				cli.StringFlag{
+					Name:  "verify",
+					Usage: "check that this file holds exactly the generated source instead of writing it",
+				},
+			},
+			Action: poseidonParamsHandler,
+		},
+		cli.Command{
+			Name:      "prove",
+			Usage:     "replay a run and check it with gnark's groth16 setup, prove and verify",
+			ArgsUsage: "<run-dir>",
+			Action:    proveHandler,
+		},
+	)
 
 	err := cliApp.Run(os.Args)
 	if err != nil {
@@ -0,0 +1,81 @@
+// Package builders wraps a frontend.Builder to intercept its range checks,
+// see profiling.Builder and export.RangeRecorder, without changing how the
+// circuit compiles otherwise.
+//
+// gnark and its standard library look up the optional interfaces of the
+// builder with type assertions: its internal key-value store and
+// frontend.Committer. A struct embedding frontend.Builder hides them, so a
+// wrapped compilation could take another path than the plain one. Wrapper
+// forwards the key-value store and Forward the other interfaces.
+package builders
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// Wrapped is a builder wrapping another one and intercepting its range
+// checks, usually by embedding a Wrapper.
+type Wrapped interface {
+	frontend.Builder
+	frontend.Rangechecker
+	keyValueStore
+
+	// Wrapped returns the builder which is wrapped.
+	Wrapped() frontend.Builder
+}
+
+// keyValueStore is the method set of gnark's internal kvstore.Store, which
+// the compiler requires from the builder.
+type keyValueStore interface {
+	SetKeyValue(key, value any)
+	GetKeyValue(key any) (value any)
+}
+
+// Wrapper is embedded by the builders wrapping another one.
+type Wrapper struct {
+	frontend.Builder
+}
+
+// SetKeyValue forwards to the wrapped builder.
+func (w *Wrapper) SetKeyValue(key, value any) {
+	w.Builder.(keyValueStore).SetKeyValue(key, value)
+}
+
+// GetKeyValue forwards to the wrapped builder.
+func (w *Wrapper) GetKeyValue(key any) any {
+	return w.Builder.(keyValueStore).GetKeyValue(key)
+}
+
+// Wrapped returns the builder which is wrapped.
+func (w *Wrapper) Wrapped() frontend.Builder {
+	return w.Builder
+}
+
+// Forward returns b augmented with the frontend.Committer of the builder
+// it wraps, when there is one. The result is the builder to hand to the
+// compiler; Unwrap recovers b from it.
+func Forward(b Wrapped) frontend.Builder {
+	if c, ok := b.Wrapped().(frontend.Committer); ok {
+		return &committer{Wrapped: b, committer: c}
+	}
+	return b
+}
+
+// Unwrap returns the builder passed to Forward, or api when it was not
+// returned by Forward.
+func Unwrap(api frontend.API) frontend.API {
+	if c, ok := api.(*committer); ok {
+		return c.Wrapped
+	}
+	return api
+}
+
+type committer struct {
+	Wrapped
+	committer frontend.Committer
+}
+
+// Commit implements frontend.Committer.
+func (c *committer) Commit(toCommit ...frontend.Variable) (frontend.Variable, error) {
+	return c.committer.Commit(toCommit...)
+}
@@ -0,0 +1,70 @@
+package builders_test
+
+import (
+	"gnark-circuit-gen/pkg/builders"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+)
+
+// ignorer is a wrapper ignoring the range checks, which the circuits of
+// the test do not request.
+type ignorer struct {
+	builders.Wrapper
+}
+
+func (ignorer) Check(frontend.Variable, int) {}
+
+// commitCircuit commits to X, which the builder must support.
+type commitCircuit struct {
+	X frontend.Variable
+}
+
+func (c *commitCircuit) Define(api frontend.API) error {
+	committer, ok := api.(frontend.Committer)
+	if !ok {
+		panic("builder is not a frontend.Committer")
+	}
+	commitment, err := committer.Commit(c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commitment, c.X)
+	return nil
+}
+
+// TestForward checks that a wrapped builder compiles a circuit committing
+// to its inputs to the same constraint system as the plain builder.
+func TestForward(t *testing.T) {
+	for name, newBuilder := range map[string]frontend.NewBuilder{"r1cs": r1cs.NewBuilder, "scs": scs.NewBuilder} {
+		plain, err := frontend.Compile(ecc.BN254.ScalarField(), newBuilder, &commitCircuit{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var wrapper *ignorer
+		wrapped, err := frontend.Compile(ecc.BN254.ScalarField(), func(field *big.Int, config frontend.CompileConfig) (frontend.Builder, error) {
+			b, err := newBuilder(field, config)
+			if err != nil {
+				return nil, err
+			}
+			wrapper = &ignorer{Wrapper: builders.Wrapper{Builder: b}}
+			forwarded := builders.Forward(wrapper)
+			if builders.Unwrap(forwarded) != frontend.API(wrapper) {
+				t.Errorf("%s: Unwrap does not return the wrapper", name)
+			}
+			return forwarded, nil
+		}, &commitCircuit{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if plain.GetNbConstraints() != wrapped.GetNbConstraints() {
+			t.Errorf("%s: wrapped builder compiles %d constraints, the plain one %d", name, wrapped.GetNbConstraints(), plain.GetNbConstraints())
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package bigmul
+
+import (
+	"fmt"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/fields"
+	"gnark-circuit-gen/pkg/profiling"
+	"gnark-circuit-gen/pkg/utils"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/logger"
+)
+
+// BigMulCircuit chains Muls multiplications of the public operands A[0],
+// A[1], ... in an emulated prime field chosen at run time, and asserts that
+// their product is the public Product.
+type BigMulCircuit struct {
+	A       []Operand `gnark:",public"`
+	Product Operand   `gnark:",public"`
+
+	field *fields.Field `gnark:"-"`
+}
+
+// Operand holds the limbs of an emulated element.
+type Operand struct {
+	Limbs []frontend.Variable
+}
+
+func init() {
+	registry.Register(registry.TestCase{
+		Name:        "bigmul",
+		Description: "chained multiplications in an emulated prime field of configurable size",
+		Params: []registry.Param{
+			{Name: "modulus-bits", Usage: "bit length of the modulus, the next prime above 2^(bits-1)", Default: 2048},
+			{Name: "limb-bits", Usage: "width of the limbs, at least 3 and at most half the native field", Default: 64},
+			{Name: "limbs", Usage: "number of limbs, which sets their width instead of limb-bits, 0 to use limb-bits", Default: 0},
+			{Name: "muls", Usage: "number of chained multiplications", Default: 2},
+		},
+		RandomCircuit: RandomCircuit,
+		Details:       Details,
+	})
+}
+
+// maxModulusBits bounds modulus-bits: the modulus is searched for at every
+// run and the circuit grows quadratically with its size.
+const maxModulusBits = 4096
+
+var (
+	primesLock sync.Mutex
+	primes     = map[int]*big.Int{}
+)
+
+// Modulus returns the next prime above 2^(bits-1), which has bits bits.
+// NextPrime[2^2047] is the modulus of test2.
+func Modulus(bits int) (*big.Int, error) {
+	if bits < 3 || bits > maxModulusBits {
+		return nil, fmt.Errorf("modulus-bits must be in [3, %d], got %d", maxModulusBits, bits)
+	}
+
+	primesLock.Lock()
+	defer primesLock.Unlock()
+	if p, ok := primes[bits]; ok {
+		return p, nil
+	}
+	p := utils.NextPrime(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)))
+	primes[bits] = p
+	return p, nil
+}
+
+// field registers the emulated field of args. gnark's emulated fields take
+// exactly as many limbs as the modulus needs, so limbs sets their width to
+// the smallest one giving that many limbs, and is rejected when no width
+// does.
+func field(args registry.Args) (*fields.Field, error) {
+	modulus, err := Modulus(args.Int("modulus-bits"))
+	if err != nil {
+		return nil, err
+	}
+	bits := args.Int("limb-bits")
+	if limbs := args.Int("limbs"); limbs != 0 {
+		if limbs < 0 {
+			return nil, fmt.Errorf("limbs must be positive, got %d", limbs)
+		}
+		bits = (modulus.BitLen() + limbs - 1) / limbs
+		if n := (modulus.BitLen() + bits - 1) / bits; n != limbs {
+			return nil, fmt.Errorf("a %d-bit modulus cannot be split into %d limbs: %d-bit limbs give %d", modulus.BitLen(), limbs, bits, n)
+		}
+	}
+	if bits < 3 {
+		return nil, fmt.Errorf("limbs must have at least 3 bits, got %d", bits)
+	}
+	return fields.Register(fields.NewParams(modulus, uint(bits)))
+}
+
+// Details records the generated modulus and the limb decomposition.
+func Details(args registry.Args) (map[string]string, error) {
+	f, err := field(args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"modulus":      f.Modulus.String(),
+		"modulus_bits": strconv.Itoa(f.Modulus.BitLen()),
+		"limb_bits":    strconv.Itoa(int(f.BitsPerLimb)),
+		"limbs":        strconv.Itoa(int(f.NbLimbs)),
+	}, nil
+}
+
+func RandomCircuit(args registry.Args) (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
+	log := logger.Logger().With().Logger()
+
+	muls := args.Int("muls")
+	if muls < 1 {
+		err = fmt.Errorf("muls must be at least 1, got %d", muls)
+		return
+	}
+	f, err := field(args)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("generating random values")
+
+	c := &BigMulCircuit{field: f}
+	a := &BigMulCircuit{field: f}
+	product := big.NewInt(1)
+	for i := 0; i <= muls; i++ {
+		var v *big.Int
+		v, err = utils.RandFieldElement(args.Rand, f.Modulus)
+		if err != nil {
+			return
+		}
+		c.A = append(c.A, Operand{Limbs: make([]frontend.Variable, f.NbLimbs)})
+		a.A = append(a.A, Operand{Limbs: f.Limbs(v)})
+		product.Mod(product.Mul(product, v), f.Modulus)
+	}
+
+	log.Info().Msg("computing outputs")
+
+	c.Product = Operand{Limbs: make([]frontend.Variable, f.NbLimbs)}
+	a.Product = Operand{Limbs: f.Limbs(product)}
+
+	log.Info().Msg("constructing circuit")
+
+	return c, a, nil
+}
+
+func (c *BigMulCircuit) Define(api frontend.API) error {
+	log := logger.Logger().With().Logger()
+
+	log.Info().Msg("build circuit now")
+
+	// the product of two limbs must fit in the native field
+	if max := (api.Compiler().FieldBitLen() - 1) / 2; int(c.field.BitsPerLimb) > max {
+		return fmt.Errorf("limbs of a %d-bit native field have at most %d bits, got %d", api.Compiler().FieldBitLen(), max, c.field.BitsPerLimb)
+	}
+	fieldApi, err := c.field.NewAPI(api)
+	if err != nil {
+		return err
+	}
+
+	/*
+	 * Non native Mul, chained
+	 */
+	acc := fieldApi.NewElement(c.A[0].Limbs)
+	for i := 1; i < len(c.A); i++ {
+		err := profiling.Region(api, fmt.Sprintf("Non native Mul %d", i), func() error {
+			acc = fieldApi.Mul(acc, fieldApi.NewElement(c.A[i].Limbs))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fieldApi.AssertIsEqual(acc, fieldApi.NewElement(c.Product.Limbs))
+	return nil
+}
@@ -0,0 +1,45 @@
+package circuit_gen
+
+import (
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/utils"
+	"io"
+	"math/big"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+)
+
+// writeCircom writes r1cs to circuit.r1cs with its wire labels in
+// circuit.sym, and every assignment to the .wtns file named after its
+// assignment file.
+func writeCircom(dir string, r1cs constraint.R1CS, curve ecc.ID, values [][]*big.Int) error {
+	modulus := curve.ScalarField()
+	exported := export.NewR1CS(r1cs)
+
+	files := map[string]func(w io.Writer) error{
+		"circuit.r1cs": func(w io.Writer) error {
+			return export.WriteCircomR1CS(w, exported, modulus)
+		},
+		"circuit.sym": func(w io.Writer) error {
+			return export.WriteCircomSym(w, exported, r1cs.VariableToString)
+		},
+	}
+	for i := range values {
+		i := i
+		files[export.WitnessFile(i, len(values))] = func(w io.Writer) error {
+			return export.WriteCircomWtns(w, values[i], modulus)
+		}
+	}
+
+	for name, write := range files {
+		err := utils.WriteFileAtomic(filepath.Join(dir, name), func(path string) error {
+			return export.SerializeCircom(path, write)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package circuit_gen
+
+import (
+	"fmt"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/utils"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Format is the encoding of the artifacts of a run.
+type Format string
+
+const (
+	// CBOR only writes the CBOR artifacts consumed by the provers.
+	CBOR Format = "cbor"
+	// JSON additionally writes a JSON rendering of every CBOR artifact next
+	// to it, for debugging, see Dump.
+	JSON Format = "json"
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case CBOR, JSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, available: %s, %s", name, CBOR, JSON)
+	}
+}
+
+// Dump renders the CBOR artifact at path as JSON, its kind being derived
+// from its file name: r1cs.cbor, scs.cbor, lookup.cbor, or an assignment.
+// Wires are named after the circuit of the run.json next to path, when
+// there is one.
+func Dump(path string, curve ecc.ID, hex bool) ([]byte, error) {
+	names, err := wireNames(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return dump(path, curve, hex, names)
+}
+
+// dump is Dump with the wire names of the run already computed.
+func dump(path string, curve ecc.ID, hex bool, names []string) ([]byte, error) {
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "scs.cbor")); err == nil && len(names) > 0 {
+		// sparse systems have no constant one wire
+		names = names[1:]
+	}
+	f := export.Formatter{Modulus: curve.ScalarField(), Hex: hex, Names: names}
+
+	var v any
+	switch filepath.Base(path) {
+	case "r1cs.cbor":
+		r1cs := &export.R1CS{}
+		if err := export.ReadCBOR(path, r1cs); err != nil {
+			return nil, err
+		}
+		v = f.R1CS(r1cs)
+	case "scs.cbor":
+		scs := &export.SparseR1CS{}
+		if err := export.ReadCBOR(path, scs); err != nil {
+			return nil, err
+		}
+		v = f.SparseR1CS(scs)
+	case "lookup.cbor":
+		lookup := &export.Lookup{}
+		if err := export.ReadCBOR(path, lookup); err != nil {
+			return nil, err
+		}
+		v = f.Lookup(lookup)
+	default:
+		assignment := &export.Assignment{}
+		if err := export.ReadCBOR(path, assignment); err != nil {
+			return nil, err
+		}
+		v = f.Assignment(assignment.Values())
+	}
+	return export.JSON(v)
+}
+
+// dumpDir writes the Dump of every CBOR artifact of dir next to it, e.g.
+// r1cs.json for r1cs.cbor. The circuit is replayed once for all of them.
+func dumpDir(dir string, curve ecc.ID, hex bool) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.cbor"))
+	if err != nil {
+		return err
+	}
+	names, err := wireNames(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := dump(path, curve, hex, names)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		err = utils.WriteFileAtomic(strings.TrimSuffix(path, ".cbor")+".json", func(path string) error {
+			return os.WriteFile(path, data, 0o644)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wireNames replays the circuit of the run.json in dir to name its public
+// and secret wires, see export.WireNames. It returns no names when dir holds
+// no run.json.
+func wireNames(dir string) ([]string, error) {
+	info, err := ReadRunInfo(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	testCase, err := info.testCase(dir)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := curves.Parse(info.Curve)
+	if err != nil {
+		return nil, err
+	}
+	circuit, _, err := testCase.RandomCircuit(registry.Args{
+		Params: info.Params,
+		Curve:  curve,
+		Rand:   utils.NewSeededReader(info.Seed),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export.WireNames(circuit)
+}
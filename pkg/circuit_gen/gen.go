@@ -1,10 +1,23 @@
 package circuit_gen
 
 import (
-	"gnark-circuit-gen/pkg/circuit_gen/test1"
-	"gnark-circuit-gen/pkg/circuit_gen/test2"
-	"gnark-circuit-gen/pkg/circuit_gen/test3"
+	"encoding/json"
+	"fmt"
+	_ "gnark-circuit-gen/pkg/circuit_gen/bigmul"
+	_ "gnark-circuit-gen/pkg/circuit_gen/merkle"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/circuit_gen/spec"
+	_ "gnark-circuit-gen/pkg/circuit_gen/test1"
+	_ "gnark-circuit-gen/pkg/circuit_gen/test2"
+	_ "gnark-circuit-gen/pkg/circuit_gen/test3"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/stats"
+	"gnark-circuit-gen/pkg/utils"
 	"math/big"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/constraint"
@@ -16,40 +29,144 @@ import (
 	"github.com/consensys/gnark/std/utils/export_utils"
 )
 
-type TestCase uint32
+// Run generates the artifacts of the test case registered under name and
+// returns the directory they were written to. An unknown name results in a
+// *registry.UnknownTestCaseError.
+func Run(name string, args registry.Args, opts Options) (dir string, err error) {
+	testCase, err := registry.Lookup(name)
+	if err != nil {
+		return
+	}
+	return run(testCase, args, opts, nil)
+}
 
-const (
-	Test1 TestCase = 1
-	Test2 TestCase = 2
-	Test3 TestCase = 3
-)
+// RunSpec generates the artifacts of the test case described by the spec
+// file at path, see package spec, and returns the directory they were
+// written to. The spec is copied to the run directory, so that the run can
+// be replayed.
+func RunSpec(path string, opts Options) (dir string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	s, err := spec.Parse(data)
+	if err != nil {
+		return
+	}
+	testCase, err := s.TestCase()
+	if err != nil {
+		return
+	}
+	return run(testCase, testCase.DefaultArgs(), opts, &specFile{name: "spec" + filepath.Ext(path), data: data})
+}
 
-func Run(testCase TestCase) (err error) {
+// specFile is the spec a test case was built from.
+type specFile struct {
+	name string
+	data []byte
+}
 
-	var circuit, assignment frontend.Circuit = nil, nil
-	switch {
-	case testCase == Test1:
-		circuit, assignment, err = test1.RandomCircuit()
-	case testCase == Test2:
-		circuit, assignment, err = test2.RandomCircuit()
-	case testCase == Test3:
-		circuit, assignment, err = test3.RandomCircuit()
-	default:
-		panic("Unknown TestCase")
+func run(testCase registry.TestCase, args registry.Args, opts Options, specFile *specFile) (dir string, err error) {
+	log := logger.Logger().With().Logger()
+
+	curve := opts.curve()
+	err = testCase.SupportsCurve(curve)
+	if err != nil {
+		return
+	}
+
+	if opts.Invalid && opts.backend() != R1CS {
+		err = fmt.Errorf("invalid assignments are only supported by the %s backend", R1CS)
+		return
+	}
+	if opts.Circom && opts.backend() != R1CS {
+		err = fmt.Errorf("circom export is only supported by the %s backend", R1CS)
+		return
 	}
 
+	seed, err := opts.seed()
 	if err != nil {
 		return
 	}
-	err = genForCircuit(circuit, assignment)
+	log.Info().Msgf("using seed %d", seed)
+	args.Curve, args.Rand = curve, utils.NewSeededReader(seed)
+
+	circuit, assignment, err := testCase.RandomCircuit(args)
 	if err != nil {
 		return
 	}
+	assignments := []frontend.Circuit{assignment}
+	for len(assignments) < opts.witnesses() {
+		_, assignment, err = testCase.RandomCircuit(args)
+		if err != nil {
+			return
+		}
+		assignments = append(assignments, assignment)
+	}
 
-	return nil
+	dir, err = opts.runDir(testCase.Name)
+	if err != nil {
+		return
+	}
+	info := RunInfo{
+		Case:      testCase.Name,
+		Curve:     curves.Name(curve),
+		Backend:   opts.backend(),
+		Witnesses: opts.witnesses(),
+		Invalid:   opts.Invalid,
+		Circom:    opts.Circom,
+		Seed:      seed,
+		Params:    args.Params,
+	}
+	if testCase.Details != nil {
+		info.Details, err = testCase.Details(args)
+		if err != nil {
+			return
+		}
+	}
+	if specFile != nil {
+		info.Spec = specFile.name
+		err = utils.WriteFileAtomic(filepath.Join(dir, specFile.name), func(path string) error {
+			return os.WriteFile(path, specFile.data, 0o644)
+		})
+		if err != nil {
+			return
+		}
+	}
+	err = writeRunInfo(dir, info)
+	if err != nil {
+		return
+	}
+	switch opts.backend() {
+	case SCS:
+		err = genSparseForCircuit(circuit, assignments, curve, dir, newProfiler(opts.Profile, dir))
+	default:
+		err = genForCircuit(circuit, assignments, curve, dir, newProfiler(opts.Profile, dir), newInvalidWriter(opts.Invalid, args.Rand), opts.Circom)
+	}
+	if err != nil {
+		return
+	}
+
+	if opts.Format == JSON {
+		err = dumpDir(dir, curve, opts.Hex)
+		if err != nil {
+			return
+		}
+	}
+	return dir, nil
+}
+
+func writeRunInfo(dir string, info RunInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(dir, "run.json"), func(path string) error {
+		return os.WriteFile(path, data, 0o644)
+	})
 }
 
-func genForCircuit(circuit frontend.Circuit, assignment frontend.Circuit) error {
+func genForCircuit(circuit frontend.Circuit, assignments []frontend.Circuit, curve ecc.ID, dir string, prof *profiler, invalid *invalidWriter, circom bool) error {
 	log := logger.Logger().With().Logger()
 
 	newBuilder := r1cs.NewBuilder
@@ -57,25 +174,41 @@ func genForCircuit(circuit frontend.Circuit, assignment frontend.Circuit) error
 	var newBuilderWrapper = func(a *big.Int, b frontend.CompileConfig) (c frontend.Builder, d error) {
 		c, d = newBuilder(a, b)
 		builder = c
+		c = prof.wrap(c)
 		return
 	}
 
-	r1cs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), newBuilderWrapper, circuit)
+	timings := &stats.Timings{}
+	start := time.Now()
+
+	log.Info().Msgf("compiling circuit for %s", curves.Name(curve))
+	prof.start()
+	r1cs, err := frontend.Compile(curve.ScalarField(), newBuilderWrapper, circuit)
+	prof.stop()
 	if err != nil {
 		log.Error().Msgf("error in building circuit: %s", err)
 		return err
 	}
-
-	log.Info().Msg("running gnark solver to generate solution (assignment)")
-	witness, err := frontend.NewWitness(assignment, ecc.BLS12_377.ScalarField())
+	timings.Compile = stats.Duration(time.Since(start))
+	err = prof.write(r1cs.GetNbConstraints())
 	if err != nil {
 		return err
 	}
-	_solution, err := r1cs.Solve(witness)
+
+	log.Info().Msgf("running gnark solver to generate %d solution(s) (assignment)", len(assignments))
+	start = time.Now()
+	solutions, err := solveAll(r1cs, curve, assignments)
 	if err != nil {
 		return err
 	}
-	solution := _solution.(*cs.R1CSSolution)
+	values := make([][]*big.Int, len(solutions))
+	for i, solution := range solutions {
+		values[i], err = export.WireValues(solution)
+		if err != nil {
+			return err
+		}
+	}
+	timings.Solve = stats.Duration(time.Since(start))
 
 	log.Info().Msgf("---------- [start] r1cs info   ----------")
 
@@ -84,32 +217,70 @@ func genForCircuit(circuit frontend.Circuit, assignment frontend.Circuit) error
 	log.Info().Msgf("r1cs.GetNbSecretVariables(): %d", r1cs.GetNbSecretVariables())
 	log.Info().Msgf("r1cs.GetNbPublicVariables(): %d", r1cs.GetNbPublicVariables())
 	log.Info().Msgf("r1cs.GetNbInternalVariables(): %d", r1cs.GetNbInternalVariables())
-	log.Info().Msgf("solution.W.Len(): %d", solution.W.Len())
+	log.Info().Msgf("solution.W.Len(): %d", len(values[0]))
 	log.Info().Msgf("---------- [ end ] r1cs info   ----------")
 
 	{
 		log.Info().Msgf("---------- [start] export r1cs ----------")
+		start = time.Now()
 		r1cs := r1cs.(constraint.R1CS)
 
 		/* r1cs.cbor */
-		err = export_utils.SerializeR1CS(r1cs, "output/r1cs.cbor")
+		err = utils.WriteFileAtomic(filepath.Join(dir, "r1cs.cbor"), func(path string) error {
+			return export_utils.SerializeR1CS(r1cs, path)
+		})
 		if err != nil {
 			return err
 		}
 
-		/* assignment.cbor */
-		err = export_utils.SerializeAssignment(r1cs, solution, "output/assignment.cbor")
+		/* assignment.cbor, or assignment_0000.cbor, ... */
+		err = writeAssignments(dir, len(solutions), func(i int, path string) error {
+			// export_utils only knows about BLS12-377 solutions
+			if solution, ok := solutions[i].(*cs.R1CSSolution); ok {
+				return export_utils.SerializeAssignment(r1cs, solution, path)
+			}
+			return export.SerializeAssignment(r1cs, values[i], path)
+		})
 		if err != nil {
 			return err
 		}
 
 		/* lookup.cbor */
 		lookup := varuna.GetLookupByBuilder(builder)
-		err = export_utils.SerializeLookup(lookup, r1cs, "output/lookup.cbor")
+		err = utils.WriteFileAtomic(filepath.Join(dir, "lookup.cbor"), func(path string) error {
+			return export_utils.SerializeLookup(lookup, r1cs, path)
+		})
+		if err != nil {
+			return err
+		}
+
+		/* circuit.r1cs, circuit.sym, assignment.wtns, ... */
+		if circom {
+			err = writeCircom(dir, r1cs, curve, values)
+			if err != nil {
+				return err
+			}
+		}
+
+		/* invalid_0000.cbor, ... */
+		err = invalid.write(dir, r1cs, curve, values[0])
 		if err != nil {
 			return err
 		}
+		timings.Export = stats.Duration(time.Since(start))
+		log.Info().Msgf("artifacts written to %s", dir)
 		log.Info().Msgf("---------- [ end ] export r1cs ----------")
 	}
-	return nil
+	return writeStats(dir, timings)
+}
+
+// writeStats computes the statistics of the artifacts written to dir and
+// saves them, together with the timings of the run, to stats.json.
+func writeStats(dir string, timings *stats.Timings) error {
+	report, err := stats.FromDir(dir)
+	if err != nil {
+		return err
+	}
+	report.Timings = timings
+	return report.Write(dir)
 }
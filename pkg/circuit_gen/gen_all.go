@@ -0,0 +1,87 @@
+package circuit_gen
+
+import (
+	"errors"
+	"fmt"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/stats"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of the generation of one test case by RunAll.
+type Result struct {
+	Case          string
+	Dir           string
+	Err           error
+	NbConstraints int
+	Duration      time.Duration
+}
+
+// RunAll generates every registered test case with its default parameters,
+// running at most jobs of them concurrently. A failing case does not stop the
+// others; the results are returned in the order of registry.List.
+func RunAll(opts Options, jobs int) ([]Result, error) {
+	if jobs < 1 {
+		return nil, fmt.Errorf("jobs must be at least 1, got %d", jobs)
+	}
+	if opts.Profile && jobs > 1 {
+		return nil, errors.New("profiling requires jobs to be 1, gnark's profiler is not thread safe")
+	}
+
+	testCases := registry.List()
+	results := make([]Result, len(testCases))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+	for i, testCase := range testCases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, testCase registry.TestCase) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = runOne(testCase, opts)
+		}(i, testCase)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func runOne(testCase registry.TestCase, opts Options) (res Result) {
+	res.Case = testCase.Name
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			res.Err = fmt.Errorf("panic: %v", r)
+		}
+		res.Duration = time.Since(start)
+	}()
+
+	res.Dir, res.Err = Run(testCase.Name, testCase.DefaultArgs(), opts)
+	if res.Err != nil {
+		return
+	}
+	if report, err := stats.Read(res.Dir); err == nil {
+		res.NbConstraints = report.NbConstraints
+	}
+	return
+}
+
+// Summary formats results as a plain-text table.
+func Summary(results []Result) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%-12s  %-7s  %12s  %10s  %s\n", "case", "status", "constraints", "duration", "output / error")
+	for _, r := range results {
+		status, detail := "ok", r.Dir
+		if r.Err != nil {
+			status, detail = "FAILED", r.Err.Error()
+		}
+		fmt.Fprintf(&sb, "%-12s  %-7s  %12d  %10s  %s\n", r.Case, status, r.NbConstraints, r.Duration.Round(time.Millisecond), detail)
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package circuit_gen
+
+import (
+	"gnark-circuit-gen/pkg/builders"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/stats"
+	"gnark-circuit-gen/pkg/utils"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/logger"
+)
+
+// genSparseForCircuit is the PLONK counterpart of genForCircuit: the circuit
+// is compiled to a SparseR1CS and its gates are written to scs.cbor. The
+// range checks are recorded by an export.RangeRecorder and exported to
+// lookup.cbor.
+func genSparseForCircuit(circuit frontend.Circuit, assignments []frontend.Circuit, curve ecc.ID, dir string, prof *profiler) error {
+	log := logger.Logger().With().Logger()
+
+	var recorder *export.RangeRecorder = nil
+	var newBuilderWrapper = func(a *big.Int, b frontend.CompileConfig) (frontend.Builder, error) {
+		c, err := scs.NewBuilder(a, b)
+		if err != nil {
+			return nil, err
+		}
+		recorder = export.NewRangeRecorder(c)
+		return prof.wrap(builders.Forward(recorder)), nil
+	}
+
+	timings := &stats.Timings{}
+	start := time.Now()
+
+	log.Info().Msgf("compiling circuit for %s", curves.Name(curve))
+	prof.start()
+	ccs, err := frontend.Compile(curve.ScalarField(), newBuilderWrapper, circuit)
+	prof.stop()
+	if err != nil {
+		log.Error().Msgf("error in building circuit: %s", err)
+		return err
+	}
+	spr := ccs.(constraint.SparseR1CS)
+	timings.Compile = stats.Duration(time.Since(start))
+	err = prof.write(spr.GetNbConstraints())
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("running gnark solver to generate %d solution(s) (assignment)", len(assignments))
+	start = time.Now()
+	solutions, err := solveAll(spr, curve, assignments)
+	if err != nil {
+		return err
+	}
+	values := make([][]*big.Int, len(solutions))
+	for i, solution := range solutions {
+		values[i], err = export.SparseWireValues(spr, solution)
+		if err != nil {
+			return err
+		}
+	}
+	lookup := recorder.Lookup(spr)
+	timings.Solve = stats.Duration(time.Since(start))
+
+	log.Info().Msgf("---------- [start] scs info    ----------")
+
+	log.Info().Msgf("scs.GetNbCoefficients(): %d", spr.GetNbCoefficients())
+	log.Info().Msgf("scs.GetNbConstraints(): %d", spr.GetNbConstraints())
+	log.Info().Msgf("scs.GetNbSecretVariables(): %d", spr.GetNbSecretVariables())
+	log.Info().Msgf("scs.GetNbPublicVariables(): %d", spr.GetNbPublicVariables())
+	log.Info().Msgf("scs.GetNbInternalVariables(): %d", spr.GetNbInternalVariables())
+	log.Info().Msgf("len(values): %d", len(values[0]))
+	log.Info().Msgf("---------- [ end ] scs info    ----------")
+
+	log.Info().Msgf("---------- [start] export scs  ----------")
+	start = time.Now()
+
+	/* scs.cbor */
+	err = utils.WriteFileAtomic(filepath.Join(dir, "scs.cbor"), func(path string) error {
+		return export.SerializeSparseR1CS(spr, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	/* assignment.cbor, or assignment_0000.cbor, ... */
+	err = writeAssignments(dir, len(solutions), func(i int, path string) error {
+		return export.SerializeAssignment(spr, values[i], path)
+	})
+	if err != nil {
+		return err
+	}
+
+	/* lookup.cbor */
+	err = utils.WriteFileAtomic(filepath.Join(dir, "lookup.cbor"), func(path string) error {
+		return export.SerializeLookup(lookup, path)
+	})
+	if err != nil {
+		return err
+	}
+	timings.Export = stats.Duration(time.Since(start))
+	log.Info().Msgf("artifacts written to %s", dir)
+	log.Info().Msgf("---------- [ end ] export scs  ----------")
+
+	return writeStats(dir, timings)
+}
@@ -0,0 +1,250 @@
+package circuit_gen_test
+
+import (
+	"encoding/json"
+	"errors"
+	"gnark-circuit-gen/pkg/circuit_gen"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/circuit_gen/spec"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/mutate"
+	"gnark-circuit-gen/pkg/utils"
+	"gnark-circuit-gen/pkg/verify"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+)
+
+// TestRoundTrip generates every registered test case and reads its
+// artifacts back with verify.Dir, which decodes them with the layouts of
+// package export. The invalid assignments must be rejected where expected,
+// so that the check cannot pass on an empty constraint system or lookup.
+func TestRoundTrip(t *testing.T) {
+	for _, testCase := range registry.List() {
+		testCase := testCase
+		t.Run(testCase.Name, func(t *testing.T) {
+			dir := generate(t, testCase.Name, testCase.DefaultArgs(), circuit_gen.Options{Witnesses: 2, Invalid: true, Format: circuit_gen.JSON})
+			if err := verify.Dir(dir, curves.Default.ScalarField()); err != nil {
+				t.Fatal(err)
+			}
+			checkInvalid(t, dir, curves.Default)
+		})
+	}
+}
+
+// TestRoundTripOptions does the same for the other curves and backends,
+// on a small bigmul circuit.
+func TestRoundTripOptions(t *testing.T) {
+	testCase, err := registry.Lookup("bigmul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := testCase.DefaultArgs()
+	args.Params["modulus-bits"] = 256
+
+	for _, curve := range curves.Supported {
+		for _, backend := range []circuit_gen.Backend{circuit_gen.R1CS, circuit_gen.SCS} {
+			curve, backend := curve, backend
+			t.Run(curves.Name(curve)+"/"+string(backend), func(t *testing.T) {
+				dir := generate(t, testCase.Name, args, circuit_gen.Options{Curve: curve, Backend: backend, Invalid: backend == circuit_gen.R1CS})
+				if err := verify.Dir(dir, curve.ScalarField()); err != nil {
+					t.Fatal(err)
+				}
+				if backend == circuit_gen.R1CS {
+					checkInvalid(t, dir, curve)
+				}
+			})
+		}
+	}
+}
+
+// TestProve replays a small bigmul run of each backend with Prove, which
+// checks the exported public inputs in the layout of the backend before
+// proving.
+func TestProve(t *testing.T) {
+	testCase, err := registry.Lookup("bigmul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := testCase.DefaultArgs()
+	args.Params["modulus-bits"] = 256
+
+	for _, backend := range []circuit_gen.Backend{circuit_gen.R1CS, circuit_gen.SCS} {
+		backend := backend
+		t.Run(string(backend), func(t *testing.T) {
+			dir := generate(t, testCase.Name, args, circuit_gen.Options{Backend: backend})
+			if _, err := circuit_gen.Prove(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestBigMulParams checks the limb decompositions bigmul derives from its
+// parameters, and that it rejects the ones gnark's emulated fields do not
+// support before compiling.
+func TestBigMulParams(t *testing.T) {
+	testCase, err := registry.Lookup("bigmul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		params          map[string]int
+		limbBits, limbs string
+		valid           bool
+	}{
+		{map[string]int{"modulus-bits": 256, "limb-bits": 64}, "64", "4", true},
+		{map[string]int{"modulus-bits": 256, "limb-bits": 100}, "100", "3", true},
+		{map[string]int{"modulus-bits": 2048, "limbs": 30}, "69", "30", true},
+		{map[string]int{"modulus-bits": 256, "limbs": 3}, "86", "3", true},
+		{map[string]int{"modulus-bits": 9, "limbs": 4}, "", "", false},
+		{map[string]int{"modulus-bits": 256, "limbs": 100}, "", "", false},
+		{map[string]int{"modulus-bits": 256, "limbs": -1}, "", "", false},
+		{map[string]int{"modulus-bits": 256, "limb-bits": 2}, "", "", false},
+		{map[string]int{"modulus-bits": 2}, "", "", false},
+		{map[string]int{"modulus-bits": 4097}, "", "", false},
+	} {
+		args := testCase.DefaultArgs()
+		for name, v := range c.params {
+			args.Params[name] = v
+		}
+		details, err := testCase.Details(args)
+		switch {
+		case !c.valid && err == nil:
+			t.Errorf("%v: expected an error, got %s limbs of %s bits", c.params, details["limbs"], details["limb_bits"])
+		case c.valid && err != nil:
+			t.Errorf("%v: %v", c.params, err)
+		case c.valid && (details["limb_bits"] != c.limbBits || details["limbs"] != c.limbs):
+			t.Errorf("%v: %s limbs of %s bits, expected %s of %s", c.params, details["limbs"], details["limb_bits"], c.limbs, c.limbBits)
+		}
+	}
+
+	// limbs of BLS12-377 have at most (253 - 1) / 2 bits
+	args := testCase.DefaultArgs()
+	args.Params["modulus-bits"], args.Params["limb-bits"] = 256, 127
+	seed := uint64(1)
+	if _, err := circuit_gen.Run(testCase.Name, args, circuit_gen.Options{OutDir: t.TempDir(), Seed: &seed}); err == nil {
+		t.Error("127-bit limbs compile for BLS12-377")
+	}
+}
+
+// TestSpecs generates the specs of specs/, whose results are public
+// outputs, and checks that the circuit of test1 rejects a wrong output.
+func TestSpecs(t *testing.T) {
+	for _, name := range []string{"test1.yaml", "test2.json"} {
+		path := filepath.Join("..", "..", "specs", name)
+		t.Run(name, func(t *testing.T) {
+			seed := uint64(1)
+			dir, err := circuit_gen.RunSpec(path, circuit_gen.Options{OutDir: t.TempDir(), Seed: &seed})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := verify.Dir(dir, curves.Default.ScalarField()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	s, err := spec.Load(filepath.Join("..", "..", "specs", "test1.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCase, err := s.TestCase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit, assignment, err := testCase.RandomCircuit(registry.Args{Curve: ecc.BLS12_377, Rand: utils.NewSeededReader(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	outputs := assignment.(*spec.Circuit).Outputs
+	if len(outputs) != len(s.Ops) {
+		t.Fatalf("%d outputs, expected one per op", len(outputs))
+	}
+	h1 := outputs[0].Limbs[0].(*big.Int)
+	outputs[0].Limbs[0] = new(big.Int).Add(h1, big.NewInt(1))
+	if err := test.IsSolved(circuit, assignment, ecc.BLS12_377.ScalarField()); err == nil {
+		t.Error("a wrong hash output is accepted")
+	}
+}
+
+func generate(t *testing.T, name string, args registry.Args, opts circuit_gen.Options) string {
+	t.Helper()
+	seed := uint64(1)
+	opts.OutDir, opts.Seed = t.TempDir(), &seed
+
+	dir, err := circuit_gen.Run(name, args, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// checkInvalid checks that every assignment of invalid.json breaks the
+// constraint or lookup entry it is expected to, and that a public input
+// flip is among them.
+func checkInvalid(t *testing.T, dir string, curve ecc.ID) {
+	t.Helper()
+	modulus := curve.ScalarField()
+
+	data, err := os.ReadFile(filepath.Join(dir, "invalid.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index []circuit_gen.InvalidAssignment
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	r1cs := &export.R1CS{}
+	if err := export.ReadCBOR(filepath.Join(dir, "r1cs.cbor"), r1cs); err != nil {
+		t.Fatal(err)
+	}
+	lookup := &export.Lookup{}
+	if err := export.ReadCBOR(filepath.Join(dir, "lookup.cbor"), lookup); err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := false
+	for _, invalid := range index {
+		flipped = flipped || invalid.Kind == mutate.FlipPublicInput
+
+		assignment := &export.Assignment{}
+		if err := export.ReadCBOR(filepath.Join(dir, invalid.File), assignment); err != nil {
+			t.Fatal(err)
+		}
+		w := assignment.Values()
+
+		var constraintErr *verify.ConstraintError
+		err := verify.R1CS(r1cs, w, modulus)
+		switch {
+		case invalid.ExpectedConstraint < 0 && err != nil:
+			t.Errorf("%s (%s): unexpected error %v", invalid.File, invalid.Kind, err)
+		case invalid.ExpectedConstraint >= 0 && !errors.As(err, &constraintErr):
+			t.Errorf("%s (%s): expected constraint %d to fail, got %v", invalid.File, invalid.Kind, invalid.ExpectedConstraint, err)
+		case invalid.ExpectedConstraint >= 0 && constraintErr.Index != invalid.ExpectedConstraint:
+			t.Errorf("%s (%s): expected constraint %d to fail, constraint %d does", invalid.File, invalid.Kind, invalid.ExpectedConstraint, constraintErr.Index)
+		}
+
+		var lookupErr *verify.LookupError
+		err = verify.Lookup(lookup, w, modulus)
+		switch expected := invalid.ExpectedLookup; {
+		case expected == nil && err != nil:
+			t.Errorf("%s (%s): unexpected error %v", invalid.File, invalid.Kind, err)
+		case expected != nil && !errors.As(err, &lookupErr):
+			t.Errorf("%s (%s): expected lookup entry %d of table %d to fail, got %v", invalid.File, invalid.Kind, expected.Entry, expected.Table, err)
+		case expected != nil && (lookupErr.Table != expected.Table || lookupErr.Index != expected.Entry):
+			t.Errorf("%s (%s): expected lookup entry %d of table %d to fail, entry %d of table %d does", invalid.File, invalid.Kind, expected.Entry, expected.Table, lookupErr.Index, lookupErr.Table)
+		}
+	}
+	if !flipped {
+		t.Errorf("no %s assignment in invalid.json", mutate.FlipPublicInput)
+	}
+}
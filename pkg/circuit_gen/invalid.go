@@ -0,0 +1,107 @@
+package circuit_gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/mutate"
+	"gnark-circuit-gen/pkg/utils"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bls12-377"
+	"github.com/consensys/gnark/logger"
+	"github.com/consensys/gnark/std/utils/export_utils"
+)
+
+// InvalidAssignment is an entry of invalid.json, describing one of the
+// invalid_NNNN.cbor assignments of a run.
+type InvalidAssignment struct {
+	File string `json:"file"`
+	*mutate.Mutation
+}
+
+// invalidWriter derives deliberately invalid assignments from the first
+// valid one. A nil *invalidWriter disables them.
+type invalidWriter struct {
+	rand io.Reader
+}
+
+func newInvalidWriter(enabled bool, rand io.Reader) *invalidWriter {
+	if !enabled {
+		return nil
+	}
+	return &invalidWriter{rand: rand}
+}
+
+// write mutates values, the solution of r1cs, once per mutate.Kinds and
+// writes invalid_0000.cbor, ... and the invalid.json index to dir. It must
+// be called once lookup.cbor is written.
+func (w *invalidWriter) write(dir string, r1cs constraint.R1CS, curve ecc.ID, values []*big.Int) error {
+	if w == nil {
+		return nil
+	}
+	log := logger.Logger().With().Logger()
+
+	lookup := &export.Lookup{}
+	if err := export.ReadCBOR(filepath.Join(dir, "lookup.cbor"), lookup); err != nil {
+		return fmt.Errorf("lookup.cbor: %w", err)
+	}
+	in := mutate.Input{
+		R1CS:    export.NewR1CS(r1cs),
+		Lookup:  lookup,
+		Values:  values,
+		Modulus: curve.ScalarField(),
+		Names:   r1cs.VariableToString,
+		Rand:    w.rand,
+	}
+
+	var index []InvalidAssignment
+	for _, kind := range mutate.Kinds {
+		m, err := mutate.New(kind, in)
+		if err != nil {
+			// not every circuit has emulated inputs or lookup entries
+			log.Warn().Msgf("skipping invalid assignment: %s", err)
+			continue
+		}
+
+		file := fmt.Sprintf("invalid_%04d.cbor", len(index))
+		err = utils.WriteFileAtomic(filepath.Join(dir, file), func(path string) error {
+			return serializeValues(r1cs, curve, m.Values, path)
+		})
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("%s: %s, expected to fail constraint %d", file, m.Description, m.ExpectedConstraint)
+		index = append(index, InvalidAssignment{File: file, Mutation: m})
+	}
+	if len(index) == 0 {
+		return fmt.Errorf("no invalid assignment could be derived")
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(dir, "invalid.json"), func(path string) error {
+		return os.WriteFile(path, data, 0o644)
+	})
+}
+
+// serializeValues writes wire values the same way the solutions of curve
+// are written.
+func serializeValues(r1cs constraint.R1CS, curve ecc.ID, values []*big.Int, path string) error {
+	if curve != ecc.BLS12_377 {
+		return export.SerializeAssignment(r1cs, values, path)
+	}
+	solution := &cs.R1CSSolution{W: make([]fr.Element, len(values))}
+	for i, v := range values {
+		solution.W[i].SetBigInt(v)
+	}
+	return export_utils.SerializeAssignment(r1cs, solution, path)
+}
@@ -0,0 +1,207 @@
+package merkle
+
+import (
+	"crypto/rand"
+	"fmt"
+	"gnark-circuit-gen/common/poseidon"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/profiling"
+	"gnark-circuit-gen/pkg/utils"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/logger"
+)
+
+// MAX_DEPTH bounds the depth of the tree, whose 2^depth leaves are all
+// hashed natively.
+const MAX_DEPTH int = 24
+
+// MerkleCircuit proves that every leaf of Proofs belongs to the Poseidon
+// Merkle tree of root Root, nodes being the BLS12377Chip.TwoToOne of their
+// children.
+type MerkleCircuit struct {
+	Root   frontend.Variable `gnark:",public"`
+	Proofs []MerkleProof
+}
+
+// MerkleProof holds a leaf, its index, whose bits from the least
+// significant one tell whether the node is the right child at each level,
+// and the siblings from the leaf up to the root.
+type MerkleProof struct {
+	Leaf  frontend.Variable
+	Index frontend.Variable
+	Path  []frontend.Variable
+}
+
+func init() {
+	registry.Register(registry.TestCase{
+		Name:        "merkle",
+		Description: "membership proofs of leaves of a random Poseidon Merkle tree",
+		Params: []registry.Param{
+			{Name: "depth", Usage: fmt.Sprintf("depth of the tree, at most %d; its 2^depth leaves are hashed natively", MAX_DEPTH), Default: 16},
+			{Name: "leaves", Usage: "number of distinct leaves proven members", Default: 1},
+		},
+		Curves:        []ecc.ID{ecc.BLS12_377}, // required by poseidon.NewBLS12377Chip
+		RandomCircuit: RandomCircuit,
+		Details:       Details,
+	})
+}
+
+func params(args registry.Args) (depth, leaves int, err error) {
+	depth, leaves = args.Int("depth"), args.Int("leaves")
+	if depth < 1 || depth > MAX_DEPTH {
+		err = fmt.Errorf("depth must be between 1 and %d, got %d", MAX_DEPTH, depth)
+	} else if leaves < 1 || leaves > 1<<depth {
+		err = fmt.Errorf("leaves must be between 1 and 2^depth = %d, got %d", 1<<depth, leaves)
+	}
+	return
+}
+
+// Details records the number of hashes of the tree and of the circuit.
+func Details(args registry.Args) (map[string]string, error) {
+	depth, leaves, err := params(args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"tree_hashes":    strconv.FormatUint(1<<depth-1, 10),
+		"circuit_hashes": strconv.Itoa(leaves * depth),
+	}, nil
+}
+
+// tree computes the root of a tree of random leaves, drawn from rand from
+// the leftmost one on, and the proofs of the selected leaves, keeping only
+// the path being hashed in memory.
+type tree struct {
+	native *poseidon.BLS12377Native
+	rand   io.Reader
+	// selected maps the index of every selected leaf to its proof
+	selected map[uint64]*proof
+}
+
+type proof struct {
+	leaf fr.Element
+	path []fr.Element
+}
+
+// root returns the root of the subtree of the given height whose leftmost
+// leaf is first, and sets the siblings at that height of its selected
+// leaves, whose indices are selected.
+func (t *tree) root(height int, first uint64, selected []uint64) (fr.Element, error) {
+	if height == 0 {
+		v, err := utils.RandFieldElement(t.rand, fr.Modulus())
+		if err != nil {
+			return fr.Element{}, err
+		}
+		var leaf fr.Element
+		leaf.SetBigInt(v)
+		if len(selected) > 0 {
+			t.selected[first].leaf = leaf
+		}
+		return leaf, nil
+	}
+
+	mid := first + 1<<(height-1)
+	var selectedLeft, selectedRight []uint64
+	for _, index := range selected {
+		if index < mid {
+			selectedLeft = append(selectedLeft, index)
+		} else {
+			selectedRight = append(selectedRight, index)
+		}
+	}
+
+	left, err := t.root(height-1, first, selectedLeft)
+	if err != nil {
+		return fr.Element{}, err
+	}
+	right, err := t.root(height-1, mid, selectedRight)
+	if err != nil {
+		return fr.Element{}, err
+	}
+	for _, index := range selectedLeft {
+		t.selected[index].path[height-1] = right
+	}
+	for _, index := range selectedRight {
+		t.selected[index].path[height-1] = left
+	}
+	return t.native.TwoToOne(left, right), nil
+}
+
+func RandomCircuit(args registry.Args) (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
+	log := logger.Logger().With().Logger()
+
+	depth, leaves, err := params(args)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("generating random values")
+
+	t := &tree{native: poseidon.NewBLS12377Native(), rand: args.Rand, selected: map[uint64]*proof{}}
+	indices := []uint64{}
+	for len(indices) < leaves {
+		var index *big.Int
+		index, err = rand.Int(args.Rand, big.NewInt(1<<depth))
+		if err != nil {
+			return
+		}
+		if _, ok := t.selected[index.Uint64()]; !ok {
+			t.selected[index.Uint64()] = &proof{path: make([]fr.Element, depth)}
+			indices = append(indices, index.Uint64())
+		}
+	}
+
+	log.Info().Msgf("hashing a tree of 2^%d leaves", depth)
+
+	root, err := t.root(depth, 0, indices)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("constructing circuit")
+
+	c := &MerkleCircuit{}
+	a := &MerkleCircuit{Root: root}
+	for _, index := range indices {
+		p := t.selected[index]
+		path := make([]frontend.Variable, depth)
+		for i := range path {
+			path[i] = p.path[i]
+		}
+		c.Proofs = append(c.Proofs, MerkleProof{Path: make([]frontend.Variable, depth)})
+		a.Proofs = append(a.Proofs, MerkleProof{Leaf: p.leaf, Index: index, Path: path})
+	}
+
+	return c, a, nil
+}
+
+func (c *MerkleCircuit) Define(api frontend.API) error {
+	log := logger.Logger().With().Logger()
+
+	log.Info().Msg("build circuit now")
+
+	chip := poseidon.NewBLS12377Chip(api)
+	for i, p := range c.Proofs {
+		err := profiling.Region(api, fmt.Sprintf("Merkle proof %d", i), func() error {
+			bits := api.ToBinary(p.Index, len(p.Path))
+			node := p.Leaf
+			for level, sibling := range p.Path {
+				left := api.Select(bits[level], sibling, node)
+				right := api.Select(bits[level], node, sibling)
+				node = chip.TwoToOne(left, right)
+			}
+			api.AssertIsEqual(node, c.Root)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package circuit_gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/circuit_gen/spec"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/utils"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Backend is the kind of constraint system a circuit is compiled to.
+type Backend string
+
+const (
+	// R1CS compiles with r1cs.NewBuilder and exports r1cs.cbor.
+	R1CS Backend = "r1cs"
+	// SCS compiles with scs.NewBuilder and exports the PLONK gates to
+	// scs.cbor.
+	SCS Backend = "scs"
+)
+
+// ParseBackend returns the backend with the given name.
+func ParseBackend(name string) (Backend, error) {
+	switch b := Backend(name); b {
+	case R1CS, SCS:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown backend %q, available: %s, %s", name, R1CS, SCS)
+	}
+}
+
+// DefaultOutDir is the directory artifacts are written to when
+// Options.OutDir is empty.
+const DefaultOutDir = "output"
+
+// Options controls where and how the artifacts of a run are written.
+type Options struct {
+	// OutDir is the root of the artifact tree; the artifacts of a run are
+	// written to <OutDir>/<case>/<RunID>/.
+	OutDir string
+	// RunID names the directory of the run. When empty, a fresh one is
+	// derived from the current time.
+	RunID string
+	// Curve selects the scalar field the circuit is compiled for, defaults to
+	// curves.Default.
+	Curve ecc.ID
+	// Backend selects the constraint system the circuit is compiled to,
+	// defaults to R1CS.
+	Backend Backend
+	// Profile attributes the constraints to the regions delimited with
+	// profiling.Region and writes profile.pprof and regions.txt.
+	Profile bool
+	// Witnesses is the number of random assignments solved against the
+	// compiled constraint system, defaults to 1.
+	Witnesses int
+	// Invalid additionally derives deliberately invalid assignments from the
+	// first one, see mutate.Kinds. Only supported by the R1CS backend.
+	Invalid bool
+	// Circom additionally writes the constraint system and the assignments in
+	// the iden3 .r1cs and .wtns formats. Only supported by the R1CS backend.
+	Circom bool
+	// Format selects the encoding of the artifacts, defaults to CBOR.
+	Format Format
+	// Hex prints field elements in hexadecimal in the JSON format.
+	Hex bool
+	// Seed makes the random assignment reproducible. When nil, a fresh seed
+	// is drawn; it is recorded in run.json either way.
+	Seed *uint64
+}
+
+// RunInfo is written to run.json and holds everything needed to replay a run.
+type RunInfo struct {
+	Case      string         `json:"case"`
+	Curve     string         `json:"curve"`
+	Backend   Backend        `json:"backend"`
+	Witnesses int            `json:"witnesses"`
+	Invalid   bool           `json:"invalid,omitempty"`
+	Circom    bool           `json:"circom,omitempty"`
+	Seed      uint64         `json:"seed"`
+	Params    map[string]int `json:"params"`
+	// Details are the values the test case derived from its parameters, see
+	// registry.TestCase.Details.
+	Details map[string]string `json:"details,omitempty"`
+	// Spec is the file name of the spec the test case was built from, see
+	// RunSpec, empty for registered test cases.
+	Spec string `json:"spec,omitempty"`
+}
+
+// ReadRunInfo reads the run.json of a run directory.
+func ReadRunInfo(dir string) (*RunInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "run.json"))
+	if err != nil {
+		return nil, err
+	}
+	info := &RunInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// testCase returns the test case of a run in dir, either registered or
+// built from its spec.
+func (info *RunInfo) testCase(dir string) (registry.TestCase, error) {
+	if info.Spec == "" {
+		return registry.Lookup(info.Case)
+	}
+	s, err := spec.Load(filepath.Join(dir, info.Spec))
+	if err != nil {
+		return registry.TestCase{}, err
+	}
+	return s.TestCase()
+}
+
+// curve returns the curve of the run.
+func (o Options) curve() ecc.ID {
+	if o.Curve == ecc.UNKNOWN {
+		return curves.Default
+	}
+	return o.Curve
+}
+
+// backend returns the backend of the run.
+func (o Options) backend() Backend {
+	if o.Backend == "" {
+		return R1CS
+	}
+	return o.Backend
+}
+
+// witnesses returns the number of assignments of the run.
+func (o Options) witnesses() int {
+	if o.Witnesses < 1 {
+		return 1
+	}
+	return o.Witnesses
+}
+
+// seed returns the seed of the run.
+func (o Options) seed() (uint64, error) {
+	if o.Seed != nil {
+		return *o.Seed, nil
+	}
+	return utils.RandSeed()
+}
+
+// runDir creates the directory the artifacts of testCase are written to.
+func (o Options) runDir(testCase string) (string, error) {
+	outDir := o.OutDir
+	if outDir == "" {
+		outDir = DefaultOutDir
+	}
+
+	if o.RunID == "" {
+		return utils.CreateRunDir(outDir, testCase)
+	}
+	dir := filepath.Join(outDir, testCase, o.RunID)
+	return dir, os.MkdirAll(dir, 0o755)
+}
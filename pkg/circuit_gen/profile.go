@@ -0,0 +1,78 @@
+package circuit_gen
+
+import (
+	"gnark-circuit-gen/pkg/builders"
+	"gnark-circuit-gen/pkg/profiling"
+	"gnark-circuit-gen/pkg/utils"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/logger"
+	"github.com/consensys/gnark/profile"
+)
+
+// profiler records the regions of a compilation and writes profile.pprof and
+// regions.txt to the run directory. A nil *profiler disables profiling.
+type profiler struct {
+	dir     string
+	session *profile.Profile
+	builder *profiling.Builder
+}
+
+func newProfiler(enabled bool, dir string) *profiler {
+	if !enabled {
+		return nil
+	}
+	return &profiler{dir: dir}
+}
+
+// start must be called right before frontend.Compile.
+func (p *profiler) start() {
+	if p == nil {
+		return
+	}
+	p.session = profile.Start(profile.WithPath(filepath.Join(p.dir, ".profile.pprof.tmp")))
+}
+
+// wrap returns the builder handed to the circuit.
+func (p *profiler) wrap(builder frontend.Builder) frontend.Builder {
+	if p == nil {
+		return builder
+	}
+	p.builder = profiling.Wrap(builder)
+	return builders.Forward(p.builder)
+}
+
+// stop must be called right after frontend.Compile, whether it succeeded or
+// not.
+func (p *profiler) stop() {
+	if p == nil {
+		return
+	}
+	p.session.Stop()
+}
+
+// write saves the profile and the region table of the compilation of a
+// system with nbConstraints constraints.
+func (p *profiler) write(nbConstraints int) error {
+	if p == nil {
+		return nil
+	}
+	log := logger.Logger().With().Logger()
+
+	tmpPath := filepath.Join(p.dir, ".profile.pprof.tmp")
+	if err := os.Rename(tmpPath, filepath.Join(p.dir, "profile.pprof")); err != nil {
+		return err
+	}
+
+	if p.session.NbConstraints() != nbConstraints {
+		log.Warn().Msgf("profile recorded %d constraints, the system has %d", p.session.NbConstraints(), nbConstraints)
+	}
+	table := profiling.Table(p.builder.Regions(), nbConstraints, p.builder.NbLookupEntries())
+	log.Info().Msgf("constraints per region:\n%s", table)
+
+	return utils.WriteFileAtomic(filepath.Join(p.dir, "regions.txt"), func(path string) error {
+		return os.WriteFile(path, []byte(table), 0o644)
+	})
+}
@@ -0,0 +1,200 @@
+package circuit_gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/curves"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/stats"
+	"gnark-circuit-gen/pkg/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/logger"
+)
+
+// ProveReport is written to prove.json by Prove.
+type ProveReport struct {
+	Curve         string        `json:"curve"`
+	NbConstraints int           `json:"nb_constraints"`
+	Timings       *ProveTimings `json:"timings"`
+}
+
+// ProveTimings are the wall-clock durations of the Groth16 phases.
+type ProveTimings struct {
+	Compile stats.Duration `json:"compile"`
+	Setup   stats.Duration `json:"setup"`
+	Prove   stats.Duration `json:"prove"`
+	Verify  stats.Duration `json:"verify"`
+}
+
+// Prove replays the run in dir from its run.json and checks the circuit
+// against gnark's Groth16: setup, prove and verify of its first assignment.
+// The proving and verifying keys, the proof and the public witness are
+// written to dir as groth16.pk, groth16.vk, groth16.proof and
+// public_witness.bin, the timings to prove.json.
+//
+// The circuit is always compiled to R1CS, whatever the backend of the run;
+// its public inputs are compared with the exported ones in the layout of
+// that backend.
+func Prove(dir string) (*ProveReport, error) {
+	log := logger.Logger().With().Logger()
+
+	info, err := ReadRunInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	testCase, err := info.testCase(dir)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := curves.Parse(info.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// same arguments as Run, so that the first assignment is reproduced
+	circuit, assignment, err := testCase.RandomCircuit(registry.Args{
+		Params: info.Params,
+		Curve:  curve,
+		Rand:   utils.NewSeededReader(info.Seed),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timings := &ProveTimings{}
+	start := time.Now()
+	log.Info().Msgf("compiling %s for %s", info.Case, info.Curve)
+	ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, circuit)
+	if err != nil {
+		return nil, err
+	}
+	timings.Compile = stats.Duration(time.Since(start))
+
+	fullWitness, err := frontend.NewWitness(assignment, curve.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPublicInputs(dir, info.Backend, ccs, fullWitness); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("groth16 setup of %d constraints", ccs.GetNbConstraints())
+	start = time.Now()
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return nil, err
+	}
+	timings.Setup = stats.Duration(time.Since(start))
+
+	start = time.Now()
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		return nil, err
+	}
+	timings.Prove = stats.Duration(time.Since(start))
+
+	start = time.Now()
+	err = groth16.Verify(proof, vk, publicWitness)
+	if err != nil {
+		return nil, err
+	}
+	timings.Verify = stats.Duration(time.Since(start))
+	log.Info().Msgf("groth16 proof verified")
+
+	for name, v := range map[string]io.WriterTo{
+		"groth16.pk":         pk,
+		"groth16.vk":         vk,
+		"groth16.proof":      proof,
+		"public_witness.bin": publicWitness,
+	} {
+		if err := writeTo(filepath.Join(dir, name), v); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &ProveReport{
+		Curve:         info.Curve,
+		NbConstraints: ccs.GetNbConstraints(),
+		Timings:       timings,
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = utils.WriteFileAtomic(filepath.Join(dir, "prove.json"), func(path string) error {
+		return os.WriteFile(path, data, 0o644)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkPublicInputs makes sure the replayed assignment is the one exported
+// to the first assignment file of dir, so that the proof is about it. The
+// public wires of the R1CS ccs start with the constant one wire, like the
+// exported ones of the r1cs backend; those of the scs backend do not.
+func checkPublicInputs(dir string, backend Backend, ccs constraint.ConstraintSystem, fullWitness witness.Witness) error {
+	index, err := export.ReadAssignmentIndex(dir)
+	if err != nil {
+		return err
+	}
+	exported := &export.Assignment{}
+	if err := export.ReadCBOR(filepath.Join(dir, index.Files[0]), exported); err != nil {
+		return fmt.Errorf("%s: %w", index.Files[0], err)
+	}
+
+	solution, err := ccs.Solve(fullWitness)
+	if err != nil {
+		return err
+	}
+	values, err := export.WireValues(solution)
+	if err != nil {
+		return err
+	}
+
+	inputs, exportedInputs := values[1:ccs.GetNbPublicVariables()], exported.Public
+	if backend != SCS {
+		if len(exportedInputs) == 0 {
+			return fmt.Errorf("%s has no constant one wire", index.Files[0])
+		}
+		exportedInputs = exportedInputs[1:]
+	}
+	if len(exportedInputs) != len(inputs) {
+		return fmt.Errorf("%s has %d public inputs, the replayed circuit %d", index.Files[0], len(exportedInputs), len(inputs))
+	}
+	for i := range inputs {
+		if inputs[i].Cmp(exportedInputs[i]) != 0 {
+			return fmt.Errorf("public input %d of %s differs from the replayed assignment", i, index.Files[0])
+		}
+	}
+	return nil
+}
+
+func writeTo(path string, v io.WriterTo) error {
+	return utils.WriteFileAtomic(path, func(path string) error {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if _, err := v.WriteTo(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"fmt"
+	"gnark-circuit-gen/pkg/curves"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Param describes an integer parameter accepted by a test case. Every
+// parameter is exposed as a command line flag of the test case subcommand.
+type Param struct {
+	Name    string
+	Usage   string
+	Default int
+}
+
+// Args carries the parameter values a test case is instantiated with.
+type Args struct {
+	Params map[string]int
+	// Curve is the curve whose scalar field the circuit is compiled for,
+	// which native outputs are computed in.
+	Curve ecc.ID
+	// Rand is the source of every random value of the assignment.
+	Rand io.Reader
+}
+
+// Int returns the value of the named parameter, or 0 when it is not set.
+func (a Args) Int(name string) int {
+	return a.Params[name]
+}
+
+// Constructor builds the circuit definition together with a random, valid
+// assignment for it.
+type Constructor func(args Args) (circuit frontend.Circuit, assignment frontend.Circuit, err error)
+
+// TestCase is a circuit that can be generated by the cli.
+type TestCase struct {
+	Name        string
+	Description string
+	Params      []Param
+	// Curves lists the curves whose scalar field the circuit can be compiled
+	// for. An empty list means every supported curve.
+	Curves        []ecc.ID
+	RandomCircuit Constructor
+	// Details optionally reports values derived from the parameters, e.g. a
+	// generated modulus, which are recorded in run.json.
+	Details func(args Args) (map[string]string, error)
+}
+
+// SupportsCurve returns an *UnsupportedCurveError if the test case cannot be
+// compiled for curve.
+func (tc TestCase) SupportsCurve(curve ecc.ID) error {
+	if len(tc.Curves) == 0 {
+		return nil
+	}
+	for _, c := range tc.Curves {
+		if c == curve {
+			return nil
+		}
+	}
+	return &UnsupportedCurveError{Name: tc.Name, Curve: curve, Supported: tc.Curves}
+}
+
+// DefaultArgs returns the Args holding the default value of every parameter.
+func (tc TestCase) DefaultArgs() Args {
+	args := Args{Params: map[string]int{}}
+	for _, p := range tc.Params {
+		args.Params[p.Name] = p.Default
+	}
+	return args
+}
+
+// UnknownTestCaseError is returned when looking up a test case which has not
+// been registered.
+type UnknownTestCaseError struct {
+	Name      string
+	Available []string
+}
+
+func (e *UnknownTestCaseError) Error() string {
+	return fmt.Sprintf("unknown test case %q, available: %s", e.Name, strings.Join(e.Available, ", "))
+}
+
+// UnsupportedCurveError is returned when a test case is compiled for a curve
+// it does not support.
+type UnsupportedCurveError struct {
+	Name      string
+	Curve     ecc.ID
+	Supported []ecc.ID
+}
+
+func (e *UnsupportedCurveError) Error() string {
+	supported := make([]string, len(e.Supported))
+	for i, c := range e.Supported {
+		supported[i] = curves.Name(c)
+	}
+	return fmt.Sprintf("test case %q does not support curve %s, supported: %s", e.Name, curves.Name(e.Curve), strings.Join(supported, ", "))
+}
+
+var (
+	mu    sync.RWMutex
+	cases = map[string]TestCase{}
+)
+
+// Register makes a test case available by its name. It is meant to be called
+// from the init function of the package defining the test case and panics if
+// the name is empty or already taken.
+func Register(tc TestCase) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if tc.Name == "" {
+		panic("registry: test case without name")
+	}
+	if tc.RandomCircuit == nil {
+		panic("registry: test case " + tc.Name + " without constructor")
+	}
+	if _, dup := cases[tc.Name]; dup {
+		panic("registry: test case " + tc.Name + " registered twice")
+	}
+	cases[tc.Name] = tc
+}
+
+// Lookup returns the test case registered under name.
+func Lookup(name string) (TestCase, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	tc, ok := cases[name]
+	if !ok {
+		return TestCase{}, &UnknownTestCaseError{Name: name, Available: names()}
+	}
+	return tc, nil
+}
+
+// List returns every registered test case sorted by name.
+func List() []TestCase {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]TestCase, 0, len(cases))
+	for _, name := range names() {
+		list = append(list, cases[name])
+	}
+	return list
+}
+
+func names() []string {
+	res := make([]string, 0, len(cases))
+	for name := range cases {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
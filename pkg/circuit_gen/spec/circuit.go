@@ -0,0 +1,417 @@
+package spec
+
+import (
+	"fmt"
+	"gnark-circuit-gen/common/poseidon"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/fields"
+	"gnark-circuit-gen/pkg/profiling"
+	"gnark-circuit-gen/pkg/utils"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/logger"
+)
+
+// Circuit is the circuit of a spec. Public[i] and Secret[i] hold the limbs
+// of the i-th public and secret inputs, in the order of the spec: a single
+// one for native variables, the limbs of X then Y for points. Outputs[i]
+// holds those of the i-th output the same way.
+type Circuit struct {
+	Public  []Value `gnark:",public"`
+	Outputs []Value `gnark:",public"`
+	Secret  []Value `gnark:",secret"`
+
+	spec *compiled `gnark:"-"`
+}
+
+// Value holds the variables of an input.
+type Value struct {
+	Limbs []frontend.Variable
+}
+
+// TestCase returns the test case of the spec. Its circuit is compiled for
+// BLS12-377 when it hashes, for any curve otherwise.
+func (s *Spec) TestCase() (registry.TestCase, error) {
+	c, err := s.compile()
+	if err != nil {
+		return registry.TestCase{}, err
+	}
+
+	testCase := registry.TestCase{
+		Name:        s.Name,
+		Description: s.Description,
+		RandomCircuit: func(args registry.Args) (frontend.Circuit, frontend.Circuit, error) {
+			return c.randomCircuit(args)
+		},
+	}
+	if c.hashes {
+		testCase.Curves = []ecc.ID{ecc.BLS12_377} // required by poseidon.NewBLS12377Chip
+	}
+	return testCase, nil
+}
+
+func (c *compiled) randomCircuit(args registry.Args) (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
+	log := logger.Logger().With().Logger()
+
+	log.Info().Msg("generating random values")
+
+	values := &Circuit{spec: c}
+	results := map[string]result{}
+	for _, in := range c.inputs {
+		v := result{kind: in.Type, field: in.field}
+		switch in.Type {
+		case Native:
+			v.native, err = utils.RandFieldElement(args.Rand, new(big.Int).Lsh(big.NewInt(1), in.Bits))
+		case Element:
+			v.elem, err = utils.RandFieldElement(args.Rand, in.field.Modulus)
+		case Point:
+			v.x, v.y, err = randPoint(args, in.Curve)
+		}
+		if err != nil {
+			return
+		}
+		results[in.Name] = v
+
+		if in.public {
+			values.Public = append(values.Public, Value{Limbs: v.limbs()})
+		} else {
+			values.Secret = append(values.Secret, Value{Limbs: v.limbs()})
+		}
+	}
+
+	log.Info().Msg("computing outputs")
+
+	if args.Curve == ecc.UNKNOWN {
+		err = fmt.Errorf("no curve to compute the native results in")
+		return
+	}
+	for i, op := range c.Ops {
+		operands := make([]result, len(op.Args))
+		for j, name := range op.Args {
+			operands[j] = results[name]
+		}
+		results[op.Out], err = evaluate(op.Op, operands, args.Curve.ScalarField())
+		if err != nil {
+			err = fmt.Errorf("op %d (%s): %w", i, op.Op, err)
+			return
+		}
+	}
+	for _, out := range c.outputs {
+		values.Outputs = append(values.Outputs, Value{Limbs: results[out.name].limbs()})
+	}
+
+	log.Info().Msg("constructing circuit")
+
+	shape := &Circuit{spec: c}
+	for _, in := range c.inputs {
+		v := Value{Limbs: make([]frontend.Variable, in.size)}
+		if in.public {
+			shape.Public = append(shape.Public, v)
+		} else {
+			shape.Secret = append(shape.Secret, v)
+		}
+	}
+	for _, out := range c.outputs {
+		shape.Outputs = append(shape.Outputs, Value{Limbs: make([]frontend.Variable, out.size)})
+	}
+	return shape, values, nil
+}
+
+// result is the value of an input or of the result of an operation,
+// computed natively.
+type result struct {
+	kind   string
+	field  *fields.Field
+	native *big.Int
+	elem   *big.Int
+	x, y   *big.Int
+}
+
+// limbs returns the variables of r: a single one for native variables, the
+// limbs of X then Y for points.
+func (r result) limbs() []frontend.Variable {
+	switch r.kind {
+	case Native:
+		return []frontend.Variable{r.native}
+	case Element:
+		return r.field.Limbs(r.elem)
+	default:
+		return append(r.field.Limbs(r.x), r.field.Limbs(r.y)...)
+	}
+}
+
+// evaluate computes natively what apply computes in the circuit, native
+// variables being elements of the field of order modulus.
+func evaluate(op string, args []result, modulus *big.Int) (result, error) {
+	if op == Hash {
+		hashValues := []*big.Int{}
+		for _, arg := range args {
+			for _, limb := range arg.limbs() {
+				hashValues = append(hashValues, limb.(*big.Int))
+			}
+		}
+		hash := poseidon.NewBLS12377Native().HashNoPad(poseidon.BLS12377Elements(hashValues...))
+		return result{kind: Native, native: hash.BigInt(new(big.Int))}, nil
+	}
+
+	a, b := args[0], args[1]
+	if a.kind == Point {
+		x, y, err := nativePointAdd(a, b)
+		return result{kind: Point, field: a.field, x: x, y: y}, err
+	}
+	res := result{kind: a.kind, field: a.field}
+	x, y, out := a.native, b.native, &res.native
+	if a.kind == Element {
+		modulus = a.field.Modulus
+		x, y, out = a.elem, b.elem, &res.elem
+	}
+
+	switch op {
+	case Add:
+		*out = new(big.Int).Add(x, y)
+	case Mul, MulMod:
+		*out = new(big.Int).Mul(x, y)
+	case Div:
+		inv := new(big.Int).ModInverse(y, modulus)
+		if inv == nil {
+			return result{}, fmt.Errorf("division by zero")
+		}
+		*out = new(big.Int).Mul(x, inv)
+	default:
+		return result{}, fmt.Errorf("unsupported operation %q", op)
+	}
+	(*out).Mod(*out, modulus)
+	return res, nil
+}
+
+// nativePointAdd adds p and q like pointAdd.
+func nativePointAdd(p, q result) (x, y *big.Int, err error) {
+	modulus := p.field.Modulus
+	qxpx := new(big.Int).Sub(q.x, p.x)
+	inv := new(big.Int).ModInverse(qxpx.Mod(qxpx, modulus), modulus)
+	if inv == nil {
+		return nil, nil, fmt.Errorf("cannot add points of the same x")
+	}
+	λ := new(big.Int).Sub(q.y, p.y)
+	λ.Mod(λ.Mul(λ, inv), modulus)
+
+	// xr = λ²-p.x-q.x
+	x = new(big.Int).Mul(λ, λ)
+	x.Sub(x, p.x).Sub(x, q.x).Mod(x, modulus)
+
+	// yr = λ(p.x-xr) - p.y
+	y = new(big.Int).Sub(p.x, x)
+	y.Mul(y, λ).Sub(y, p.y).Mod(y, modulus)
+	return x, y, nil
+}
+
+// randPoint samples a random point of curve.
+func randPoint(args registry.Args, curve string) (x, y *big.Int, err error) {
+	x, y = new(big.Int), new(big.Int)
+	switch curve {
+	case "secp256k1":
+		p, err := utils.RandP256G(args.Rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.X.BigInt(x)
+		p.Y.BigInt(y)
+	case "bn254":
+		s, err := utils.RandFieldElement(args.Rand, ecc.BN254.ScalarField())
+		if err != nil {
+			return nil, nil, err
+		}
+		_, _, g, _ := bn254.Generators()
+		var p bn254.G1Affine
+		p.ScalarMultiplication(&g, s)
+		p.X.BigInt(x)
+		p.Y.BigInt(y)
+	default:
+		return nil, nil, fmt.Errorf("cannot sample points of %q", curve)
+	}
+	return x, y, nil
+}
+
+// value is an input or the result of an operation: a native variable, an
+// emulated element or a point.
+type value struct {
+	kind   string
+	field  *fields.Field
+	native frontend.Variable
+	elem   fields.Element
+	x, y   fields.Element
+	// limbs are the variables of an input element or point, hashed as they
+	// are like in test1, test2 and test3. Results are reduced first.
+	limbs []frontend.Variable
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	log := logger.Logger().With().Logger()
+
+	log.Info().Msg("build circuit now")
+
+	apis := map[*fields.Field]fields.API{}
+	fieldAPI := func(f *fields.Field) (fields.API, error) {
+		if fa, ok := apis[f]; ok {
+			return fa, nil
+		}
+		fa, err := f.NewAPI(api)
+		apis[f] = fa
+		return fa, err
+	}
+
+	values := map[string]value{}
+	nbPublic, nbSecret := 0, 0
+	for _, in := range c.spec.inputs {
+		var limbs []frontend.Variable
+		if in.public {
+			limbs = c.Public[nbPublic].Limbs
+			nbPublic++
+		} else {
+			limbs = c.Secret[nbSecret].Limbs
+			nbSecret++
+		}
+
+		v := value{kind: in.Type, field: in.field, limbs: limbs}
+		switch in.Type {
+		case Native:
+			v.native = limbs[0]
+		case Element:
+			fa, err := fieldAPI(in.field)
+			if err != nil {
+				return err
+			}
+			v.elem = fa.NewElement(limbs)
+		case Point:
+			fa, err := fieldAPI(in.field)
+			if err != nil {
+				return err
+			}
+			n := len(limbs) / 2
+			v.x, v.y = fa.NewElement(limbs[:n]), fa.NewElement(limbs[n:])
+		}
+		values[in.Name] = v
+	}
+
+	for _, op := range c.spec.Ops {
+		err := profiling.Region(api, op.Op+" "+op.Out, func() error {
+			args := make([]value, len(op.Args))
+			for i, name := range op.Args {
+				args[i] = values[name]
+			}
+			out, err := apply(api, fieldAPI, op.Op, args)
+			values[op.Out] = out
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return profiling.Region(api, "outputs", func() error {
+		for i, out := range c.spec.outputs {
+			v, limbs := values[out.name], c.Outputs[i].Limbs
+			if out.kind == Native {
+				api.AssertIsEqual(v.native, limbs[0])
+				continue
+			}
+			fa, err := fieldAPI(out.field)
+			if err != nil {
+				return err
+			}
+			if out.kind == Element {
+				fa.AssertIsEqual(v.elem, fa.NewElement(limbs))
+			} else {
+				n := len(limbs) / 2
+				fa.AssertIsEqual(v.x, fa.NewElement(limbs[:n]))
+				fa.AssertIsEqual(v.y, fa.NewElement(limbs[n:]))
+			}
+		}
+		return nil
+	})
+}
+
+// apply computes an operation type checked by resultOf.
+func apply(api frontend.API, fieldAPI func(*fields.Field) (fields.API, error), op string, args []value) (value, error) {
+	if op == Hash {
+		hashValues := []frontend.Variable{}
+		for _, arg := range args {
+			switch {
+			case arg.kind == Native:
+				hashValues = append(hashValues, arg.native)
+				continue
+			case arg.limbs != nil:
+				hashValues = append(hashValues, arg.limbs...)
+				continue
+			}
+			fa, err := fieldAPI(arg.field)
+			if err != nil {
+				return value{}, err
+			}
+			if arg.kind == Element {
+				hashValues = append(hashValues, fa.Limbs(arg.elem)...)
+			} else {
+				hashValues = append(hashValues, fa.Limbs(arg.x)...)
+				hashValues = append(hashValues, fa.Limbs(arg.y)...)
+			}
+		}
+		hashChip := poseidon.NewBLS12377Chip(api)
+		return value{kind: Native, native: hashChip.HashNoPad(hashValues)}, nil
+	}
+
+	a, b := args[0], args[1]
+	if a.kind == Native {
+		switch op {
+		case Add:
+			return value{kind: Native, native: api.Add(a.native, b.native)}, nil
+		case Mul:
+			return value{kind: Native, native: api.Mul(a.native, b.native)}, nil
+		case Div:
+			return value{kind: Native, native: api.Div(a.native, b.native)}, nil
+		}
+	}
+
+	fa, err := fieldAPI(a.field)
+	if err != nil {
+		return value{}, err
+	}
+	res := value{kind: a.kind, field: a.field}
+	switch op {
+	case Add:
+		res.elem = fa.Add(a.elem, b.elem)
+	case Mul:
+		res.elem = fa.Mul(a.elem, b.elem)
+	case MulMod:
+		res.elem = fa.MulMod(a.elem, b.elem)
+	case Div:
+		res.elem = fa.Div(a.elem, b.elem)
+	case PointAdd:
+		res.x, res.y = pointAdd(fa, a, b)
+	default:
+		return value{}, fmt.Errorf("unsupported operation %q", op)
+	}
+	return res, nil
+}
+
+// pointAdd adds p and q, assuming p ≠ ±q, as in test1.
+func pointAdd(fa fields.API, p, q value) (x, y fields.Element) {
+	// compute λ = (q.y-p.y)/(q.x-p.x)
+	qypy := fa.Sub(q.y, p.y)
+	qxpx := fa.Sub(q.x, p.x)
+	λ := fa.Div(qypy, qxpx)
+
+	// xr = λ²-p.x-q.x
+	λλ := fa.MulMod(λ, λ)
+	qxpx = fa.Add(p.x, q.x)
+	xr := fa.Sub(λλ, qxpx)
+
+	// p.y = λ(p.x-r.x) - p.y
+	pxrx := fa.Sub(p.x, xr)
+	λpxrx := fa.MulMod(λ, pxrx)
+	yr := fa.Sub(λpxrx, p.y)
+
+	return fa.Reduce(xr), fa.Reduce(yr)
+}
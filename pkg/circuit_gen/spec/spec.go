@@ -0,0 +1,324 @@
+// Package spec builds test cases from declarative YAML or JSON files instead
+// of Go code. A spec lists emulated fields, inputs and the operations applied
+// to them:
+//
+//	name: my-case
+//	fields:
+//	  - {name: fp, modulus: secp256k1-fp}
+//	  - {name: big, modulus: "0x...", bits: 64}
+//	inputs:
+//	  - {name: G1, type: point, field: fp, curve: secp256k1, visibility: public}
+//	  - {name: A1, type: element, field: big}
+//	  - {name: N1, type: native, bits: 128, visibility: public}
+//	ops:
+//	  - {op: hash, args: [G1, A1, N1], out: H1}
+//	  - {op: mulmod, args: [A1, A1], out: A2}
+//	  - {op: point_add, args: [G1, G2], out: G3}
+//	outputs: [H1, G3]
+//
+// The results named by outputs, every result when it is omitted, are public
+// outputs of the circuit: their values are computed natively and asserted
+// equal to the ones of the circuit.
+//
+// JSON specs are read the same way, JSON being valid YAML.
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"gnark-circuit-gen/pkg/fields"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the content of a spec file.
+type Spec struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Fields      []Field `yaml:"fields"`
+	Inputs      []Input `yaml:"inputs"`
+	Ops         []Op    `yaml:"ops"`
+	// Outputs names the results of Ops bound to public outputs, all of
+	// them when empty.
+	Outputs []string `yaml:"outputs"`
+}
+
+// Field declares an emulated field. Modulus is the name of a preset, see
+// fields.Presets, or an integer. Bits is the width of the limbs, defaulting
+// to the preset's or to 64. The field has as many limbs as the modulus
+// needs, the only number gnark's emulated arithmetic accepts.
+type Field struct {
+	Name    string `yaml:"name"`
+	Modulus string `yaml:"modulus"`
+	Bits    uint   `yaml:"bits"`
+}
+
+// Input types.
+const (
+	// Native is a variable of the native field, sampled on Bits bits.
+	Native = "native"
+	// Element is an element of an emulated field.
+	Element = "element"
+	// Point is a point of Curve, with coordinates in an emulated field.
+	Point = "point"
+)
+
+// Input declares a circuit input. Visibility is "public" or "secret", the
+// default.
+type Input struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
+	Field      string `yaml:"field"`
+	Curve      string `yaml:"curve"`
+	Bits       uint   `yaml:"bits"`
+	Visibility string `yaml:"visibility"`
+}
+
+// Operations.
+const (
+	// Hash hashes the native variables and limbs of its arguments with
+	// poseidon.BLS12377Chip.HashNoPad into a native variable.
+	Hash = "hash"
+	// Add, Mul and Div apply to two native variables or two elements of the
+	// same field.
+	Add = "add"
+	Mul = "mul"
+	Div = "div"
+	// MulMod multiplies two elements and reduces the result.
+	MulMod = "mulmod"
+	// PointAdd adds two points with coordinates in the same field.
+	PointAdd = "point_add"
+)
+
+// Op applies an operation to inputs or results of previous operations and
+// names its result Out.
+type Op struct {
+	Op   string   `yaml:"op"`
+	Args []string `yaml:"args"`
+	Out  string   `yaml:"out"`
+}
+
+// Load reads and validates a spec file.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a spec. Unknown keys are rejected rather than
+// ignored.
+func Parse(data []byte) (*Spec, error) {
+	s := &Spec{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(s); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if _, err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// curveFields are the coordinate fields of the curves points are sampled
+// on.
+var curveFields = map[string]string{
+	"secp256k1": "secp256k1-fp",
+	"bn254":     "bn254-fp",
+}
+
+// compiled is a validated spec, with the layout of its inputs in the
+// circuit.
+type compiled struct {
+	*Spec
+	fields   map[string]*fields.Field
+	inputs   []input
+	nbPublic int
+	nbSecret int
+	hashes   bool
+	outputs  []output
+}
+
+// input locates the limbs of an Input among the public or secret variables.
+type input struct {
+	Input
+	field  *fields.Field
+	public bool
+	offset int
+	size   int
+}
+
+// output is a result bound to a public output, with the number of its
+// limbs.
+type output struct {
+	name  string
+	kind  string
+	field *fields.Field
+	size  int
+}
+
+func (s *Spec) compile() (*compiled, error) {
+	if s.Name == "" {
+		return nil, fmt.Errorf("spec has no name")
+	}
+	c := &compiled{Spec: s, fields: map[string]*fields.Field{}}
+
+	for _, f := range s.Fields {
+		if _, ok := c.fields[f.Name]; ok || f.Name == "" {
+			return nil, fmt.Errorf("field %q: missing or duplicate name", f.Name)
+		}
+		modulus, err := fields.ParseModulus(f.Modulus)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		params := fields.NewParams(modulus, 64)
+		if preset, ok := fields.Presets[f.Modulus]; ok {
+			params = preset
+		}
+		if f.Bits != 0 {
+			params = fields.NewParams(modulus, f.Bits)
+		}
+		c.fields[f.Name], err = fields.Register(params)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+
+	kinds := map[string]value{}
+	for _, in := range s.Inputs {
+		if _, ok := kinds[in.Name]; ok || in.Name == "" {
+			return nil, fmt.Errorf("input %q: missing or duplicate name", in.Name)
+		}
+		layout := input{Input: in}
+		switch in.Visibility {
+		case "public":
+			layout.public = true
+		case "", "secret":
+		default:
+			return nil, fmt.Errorf("input %s: unknown visibility %q", in.Name, in.Visibility)
+		}
+
+		switch in.Type {
+		case Native:
+			if in.Bits == 0 || in.Bits > 248 {
+				return nil, fmt.Errorf("input %s: bits must be in [1, 248], got %d", in.Name, in.Bits)
+			}
+			layout.size = 1
+		case Element, Point:
+			field, ok := c.fields[in.Field]
+			if !ok {
+				return nil, fmt.Errorf("input %s: unknown field %q", in.Name, in.Field)
+			}
+			layout.field = field
+			layout.size = int(field.NbLimbs)
+			if in.Type == Point {
+				preset, ok := curveFields[in.Curve]
+				if !ok {
+					return nil, fmt.Errorf("input %s: points can only be sampled on secp256k1 and bn254, got %q", in.Name, in.Curve)
+				}
+				if field.Modulus.Cmp(fields.Presets[preset].Modulus) != 0 {
+					return nil, fmt.Errorf("input %s: field %s is not the coordinate field of %s", in.Name, in.Field, in.Curve)
+				}
+				layout.size *= 2
+			}
+		default:
+			return nil, fmt.Errorf("input %s: unknown type %q", in.Name, in.Type)
+		}
+
+		if layout.public {
+			layout.offset = c.nbPublic
+			c.nbPublic += layout.size
+		} else {
+			layout.offset = c.nbSecret
+			c.nbSecret += layout.size
+		}
+		c.inputs = append(c.inputs, layout)
+		kinds[in.Name] = value{kind: in.Type, field: layout.field}
+	}
+
+	for i, op := range s.Ops {
+		if _, ok := kinds[op.Out]; ok || op.Out == "" {
+			return nil, fmt.Errorf("op %d: missing or duplicate out %q", i, op.Out)
+		}
+		args := make([]value, len(op.Args))
+		for j, name := range op.Args {
+			arg, ok := kinds[name]
+			if !ok {
+				return nil, fmt.Errorf("op %d: unknown argument %q", i, name)
+			}
+			args[j] = arg
+		}
+
+		out, err := resultOf(op.Op, args)
+		if err != nil {
+			return nil, fmt.Errorf("op %d (%s): %w", i, op.Op, err)
+		}
+		kinds[op.Out] = out
+		c.hashes = c.hashes || op.Op == Hash
+	}
+
+	names := s.Outputs
+	if len(names) == 0 {
+		for _, op := range s.Ops {
+			names = append(names, op.Out)
+		}
+	}
+	bound := map[string]bool{}
+	for _, name := range names {
+		if bound[name] {
+			return nil, fmt.Errorf("output %q: duplicate", name)
+		}
+		bound[name] = true
+		if !c.isResult(name) {
+			return nil, fmt.Errorf("output %q: not the result of an op", name)
+		}
+		out := output{name: name, kind: kinds[name].kind, field: kinds[name].field, size: 1}
+		switch out.kind {
+		case Element:
+			out.size = int(out.field.NbLimbs)
+		case Point:
+			out.size = 2 * int(out.field.NbLimbs)
+		}
+		c.outputs = append(c.outputs, out)
+	}
+	return c, nil
+}
+
+func (c *compiled) isResult(name string) bool {
+	for _, op := range c.Ops {
+		if op.Out == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resultOf type checks an operation and returns the kind of its result.
+func resultOf(op string, args []value) (value, error) {
+	if op == Hash {
+		if len(args) == 0 {
+			return value{}, fmt.Errorf("nothing to hash")
+		}
+		return value{kind: Native}, nil
+	}
+
+	if len(args) != 2 {
+		return value{}, fmt.Errorf("expected 2 arguments, got %d", len(args))
+	}
+	a, b := args[0], args[1]
+	if a.kind != b.kind || a.field != b.field {
+		return value{}, fmt.Errorf("arguments of different types")
+	}
+	switch {
+	case op == PointAdd && a.kind == Point,
+		(op == Add || op == Mul || op == Div) && a.kind != Point,
+		op == MulMod && a.kind == Element:
+		return a, nil
+	default:
+		return value{}, fmt.Errorf("unsupported on %s arguments", a.kind)
+	}
+}
@@ -3,8 +3,14 @@ package test1
 import (
 	"fmt"
 	"gnark-circuit-gen/common/poseidon"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/profiling"
 	"gnark-circuit-gen/pkg/utils"
+	"math/big"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377fr "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
 	"github.com/consensys/gnark-crypto/ecc/secp256k1/fr"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/logger"
@@ -17,41 +23,80 @@ type Test1Circuit[Base, Scalar emulated.FieldParams] struct {
 	G1, G2 sw_emulated.AffinePoint[Base] `gnark:",public"`
 	S1, S2 emulated.Element[Scalar]      `gnark:",public"`
 	N1, N2 frontend.Variable             `gnark:",public"`
+
+	// H1 and H2 are the hashes of (G1, S1, N1) and (G2, S2, N2), S3 = S1+S2
+	// and G3 = G1+G2.
+	H1, H2 frontend.Variable             `gnark:",public"`
+	S3     emulated.Element[Scalar]      `gnark:",public"`
+	G3     sw_emulated.AffinePoint[Base] `gnark:",public"`
+}
+
+func init() {
+	registry.Register(registry.TestCase{
+		Name:          "test1",
+		Description:   "generate for test 1",
+		Curves:        []ecc.ID{ecc.BLS12_377}, // required by poseidon.NewBLS12377Chip
+		RandomCircuit: RandomCircuit,
+	})
 }
 
-func RandomCircuit() (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
+func RandomCircuit(args registry.Args) (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
 	log := logger.Logger().With().Logger()
 
 	log.Info().Msg("generating random values")
 
-	G1, err := utils.RandP256G()
+	G1, err := utils.RandP256G(args.Rand)
 	if err != nil {
 		return
 	}
-	G2, err := utils.RandP256G()
+	G2, err := utils.RandP256G(args.Rand)
 	if err != nil {
 		return
 	}
 
-	S1, err := utils.RandFieldElement(fr.Modulus())
+	S1, err := utils.RandFieldElement(args.Rand, fr.Modulus())
 	if err != nil {
 		return
 	}
-	S2, err := utils.RandFieldElement(fr.Modulus())
+	S2, err := utils.RandFieldElement(args.Rand, fr.Modulus())
 	if err != nil {
 		return
 	}
 
-	N1, err := utils.Rand128Bit()
+	N1, err := utils.Rand128Bit(args.Rand)
 	if err != nil {
 		return
 	}
 
-	N2, err := utils.Rand128Bit()
+	N2, err := utils.Rand128Bit(args.Rand)
 	if err != nil {
 		return
 	}
 
+	log.Info().Msg("computing outputs")
+
+	native := poseidon.NewBLS12377Native()
+	hash := func(G secp256k1.G1Affine, S, N *big.Int) bls12377fr.Element {
+		var x, y big.Int
+		G.X.BigInt(&x)
+		G.Y.BigInt(&y)
+
+		hash_values := []*big.Int{}
+		hash_values = append(hash_values, utils.Limbs[emulated.Secp256k1Fp](&x)...)
+		hash_values = append(hash_values, utils.Limbs[emulated.Secp256k1Fp](&y)...)
+		hash_values = append(hash_values, utils.Limbs[emulated.Secp256k1Fr](S)...)
+		hash_values = append(hash_values, N)
+		return native.HashNoPad(poseidon.BLS12377Elements(hash_values...))
+	}
+	H1 := hash(G1, S1, N1)
+	H2 := hash(G2, S2, N2)
+
+	S3 := new(big.Int).Add(S1, S2)
+	S3.Mod(S3, fr.Modulus())
+
+	var G3 secp256k1.G1Affine
+	G3.Add(&G1, &G2)
+
 	log.Info().Msg("constructing circuit")
 
 	circuit = &Test1Circuit[emulated.Secp256k1Fp, emulated.Secp256k1Fr]{}
@@ -68,6 +113,13 @@ func RandomCircuit() (circuit frontend.Circuit, assignment frontend.Circuit, err
 		emulated.ValueOf[emulated.Secp256k1Fr](S2),
 		N1,
 		N2,
+		H1,
+		H2,
+		emulated.ValueOf[emulated.Secp256k1Fr](S3),
+		sw_emulated.AffinePoint[emulated.Secp256k1Fp]{
+			X: emulated.ValueOf[emulated.Secp256k1Fp](G3.X),
+			Y: emulated.ValueOf[emulated.Secp256k1Fp](G3.Y),
+		},
 	}
 
 	return
@@ -84,7 +136,7 @@ func (c *Test1Circuit[Base, Scalar]) Define(api frontend.API) error {
 	/*
 	 * Hash 1
 	 */
-	{
+	err := profiling.Region(api, "Hash 1", func() error {
 		hash_chip := poseidon.NewBLS12377Chip(api)
 		hash_values := []frontend.Variable{}
 
@@ -95,13 +147,16 @@ func (c *Test1Circuit[Base, Scalar]) Define(api frontend.API) error {
 
 		hash_values = append(hash_values, c.N1)
 
-		hash_chip.HashNoPad(hash_values)
-
+		api.AssertIsEqual(hash_chip.HashNoPad(hash_values), c.H1)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	/*
 	 * Hash 2
 	 */
-	{
+	err = profiling.Region(api, "Hash 2", func() error {
 		hash_chip := poseidon.NewBLS12377Chip(api)
 		hash_values := []frontend.Variable{}
 
@@ -112,46 +167,64 @@ func (c *Test1Circuit[Base, Scalar]) Define(api frontend.API) error {
 
 		hash_values = append(hash_values, c.N2)
 
-		hash_chip.HashNoPad(hash_values)
+		api.AssertIsEqual(hash_chip.HashNoPad(hash_values), c.H2)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	/*
 	 * Non native Add
 	 */
-	fieldApi, err := emulated.NewField[Scalar](api)
+	err = profiling.Region(api, "Non native Add", func() error {
+		fieldApi, err := emulated.NewField[Scalar](api)
+		if err != nil {
+			return err
+		}
+		fieldApi.AssertIsEqual(fieldApi.Add(&c.S1, &c.S2), &c.S3)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	fieldApi.Add(&c.S1, &c.S2)
 
 	/*
 	 * Add op for two AffinePoint
 	 * See: https://github.com/Consensys/gnark/blob/e1cb5a703defd33473e7481f8b4af9ffde7230ec/std/algebra/emulated/sw_emulated/point.go#L108
 	 */
-	baseApi, err := emulated.NewField[Base](api)
+	err = profiling.Region(api, "Point addition", func() error {
+		baseApi, err := emulated.NewField[Base](api)
+		if err != nil {
+			return err
+		}
+		p := &c.G1
+		q := &c.G2
+		// compute λ = (q.y-p.y)/(q.x-p.x)
+		qypy := baseApi.Sub(&q.Y, &p.Y)
+		qxpx := baseApi.Sub(&q.X, &p.X)
+		λ := baseApi.Div(qypy, qxpx)
+
+		// xr = λ²-p.x-q.x
+		λλ := baseApi.MulMod(λ, λ)
+		qxpx = baseApi.Add(&p.X, &q.X)
+		xr := baseApi.Sub(λλ, qxpx)
+
+		// p.y = λ(p.x-r.x) - p.y
+		pxrx := baseApi.Sub(&p.X, xr)
+		λpxrx := baseApi.MulMod(λ, pxrx)
+		yr := baseApi.Sub(λpxrx, &p.Y)
+
+		r := &sw_emulated.AffinePoint[Base]{
+			X: *baseApi.Reduce(xr),
+			Y: *baseApi.Reduce(yr),
+		}
+		baseApi.AssertIsEqual(&r.X, &c.G3.X)
+		baseApi.AssertIsEqual(&r.Y, &c.G3.Y)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	p := &c.G1
-	q := &c.G2
-	// compute λ = (q.y-p.y)/(q.x-p.x)
-	qypy := baseApi.Sub(&q.Y, &p.Y)
-	qxpx := baseApi.Sub(&q.X, &p.X)
-	λ := baseApi.Div(qypy, qxpx)
-
-	// xr = λ²-p.x-q.x
-	λλ := baseApi.MulMod(λ, λ)
-	qxpx = baseApi.Add(&p.X, &q.X)
-	xr := baseApi.Sub(λλ, qxpx)
-
-	// p.y = λ(p.x-r.x) - p.y
-	pxrx := baseApi.Sub(&p.X, xr)
-	λpxrx := baseApi.MulMod(λ, pxrx)
-	yr := baseApi.Sub(λpxrx, &p.Y)
-
-	_ = &sw_emulated.AffinePoint[Base]{
-		X: *baseApi.Reduce(xr),
-		Y: *baseApi.Reduce(yr),
-	}
 	return nil
 }
@@ -3,9 +3,13 @@ package test2
 import (
 	"fmt"
 	"gnark-circuit-gen/common/poseidon"
+	"gnark-circuit-gen/pkg/circuit_gen/registry"
+	"gnark-circuit-gen/pkg/profiling"
 	"gnark-circuit-gen/pkg/utils"
 	"math/big"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/logger"
 	"github.com/consensys/gnark/std/math/emulated"
@@ -16,6 +20,12 @@ type Test2Circuit[Field emulated.FieldParams] struct {
 	A1, B1 emulated.Element[Field] `gnark:",public"`
 	A2, B2 emulated.Element[Field] `gnark:",public"`
 	N1, N2 frontend.Variable       `gnark:",public"`
+
+	// H1 and H2 are the hashes of (A1, A2, N1) and (B1, B2, N2), P1 = A1⋅B1
+	// and P2 = A2⋅B2.
+	H1, H2 frontend.Variable       `gnark:",public"`
+	P1     emulated.Element[Field] `gnark:",public"`
+	P2     emulated.Element[Field] `gnark:",public"`
 }
 
 type thirtyTwoLimbPrimeField struct{}
@@ -34,6 +44,13 @@ func init() {
 	if _, ok := PRIME_FIELD_MODULES.SetString(PRIME_FIELD_MODULES_OCT_STRING, 10); !ok {
 		panic("invalid modulus " + PRIME_FIELD_MODULES_OCT_STRING)
 	}
+
+	registry.Register(registry.TestCase{
+		Name:          "test2",
+		Description:   "generate for test 2",
+		Curves:        []ecc.ID{ecc.BLS12_377}, // required by poseidon.NewBLS12377Chip
+		RandomCircuit: RandomCircuit,
+	})
 }
 
 type Test2The2048BitPrimeField struct{ thirtyTwoLimbPrimeField }
@@ -42,39 +59,57 @@ func (fp Test2The2048BitPrimeField) Modulus() *big.Int {
 	return new(big.Int).Set(&PRIME_FIELD_MODULES)
 }
 
-func RandomCircuit() (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
+func RandomCircuit(args registry.Args) (circuit frontend.Circuit, assignment frontend.Circuit, err error) {
 	log := logger.Logger().With().Logger()
 
 	log.Info().Msg("generating random values")
 
-	A1, err := utils.RandFieldElement(&PRIME_FIELD_MODULES)
+	A1, err := utils.RandFieldElement(args.Rand, &PRIME_FIELD_MODULES)
 	if err != nil {
 		return
 	}
-	B1, err := utils.RandFieldElement(&PRIME_FIELD_MODULES)
+	B1, err := utils.RandFieldElement(args.Rand, &PRIME_FIELD_MODULES)
 	if err != nil {
 		return
 	}
 
-	A2, err := utils.RandFieldElement(&PRIME_FIELD_MODULES)
+	A2, err := utils.RandFieldElement(args.Rand, &PRIME_FIELD_MODULES)
 	if err != nil {
 		return
 	}
-	B2, err := utils.RandFieldElement(&PRIME_FIELD_MODULES)
+	B2, err := utils.RandFieldElement(args.Rand, &PRIME_FIELD_MODULES)
 	if err != nil {
 		return
 	}
 
-	N1, err := utils.Rand128Bit()
+	N1, err := utils.Rand128Bit(args.Rand)
 	if err != nil {
 		return
 	}
 
-	N2, err := utils.Rand128Bit()
+	N2, err := utils.Rand128Bit(args.Rand)
 	if err != nil {
 		return
 	}
 
+	log.Info().Msg("computing outputs")
+
+	native := poseidon.NewBLS12377Native()
+	hash := func(E1, E2, N *big.Int) fr.Element {
+		hash_values := []*big.Int{}
+		hash_values = append(hash_values, utils.Limbs[Test2The2048BitPrimeField](E1)...)
+		hash_values = append(hash_values, utils.Limbs[Test2The2048BitPrimeField](E2)...)
+		hash_values = append(hash_values, N)
+		return native.HashNoPad(poseidon.BLS12377Elements(hash_values...))
+	}
+	H1 := hash(A1, A2, N1)
+	H2 := hash(B1, B2, N2)
+
+	P1 := new(big.Int).Mul(A1, B1)
+	P1.Mod(P1, &PRIME_FIELD_MODULES)
+	P2 := new(big.Int).Mul(A2, B2)
+	P2.Mod(P2, &PRIME_FIELD_MODULES)
+
 	log.Info().Msg("constructing circuit")
 
 	circuit = &Test2Circuit[Test2The2048BitPrimeField]{}
@@ -85,6 +120,10 @@ func RandomCircuit() (circuit frontend.Circuit, assignment frontend.Circuit, err
 		emulated.ValueOf[Test2The2048BitPrimeField](B2),
 		N1,
 		N2,
+		H1,
+		H2,
+		emulated.ValueOf[Test2The2048BitPrimeField](P1),
+		emulated.ValueOf[Test2The2048BitPrimeField](P2),
 	}
 
 	return
@@ -101,7 +140,7 @@ func (c *Test2Circuit[Field]) Define(api frontend.API) error {
 	/*
 	 * Hash 1
 	 */
-	{
+	err := profiling.Region(api, "Hash 1", func() error {
 		hash_chip := poseidon.NewBLS12377Chip(api)
 		hash_values := []frontend.Variable{}
 
@@ -109,13 +148,16 @@ func (c *Test2Circuit[Field]) Define(api frontend.API) error {
 		hash_values = append(hash_values, c.A2.Limbs...)
 		hash_values = append(hash_values, c.N1)
 
-		hash_chip.HashNoPad(hash_values)
-
+		api.AssertIsEqual(hash_chip.HashNoPad(hash_values), c.H1)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	/*
 	 * Hash 2
 	 */
-	{
+	err = profiling.Region(api, "Hash 2", func() error {
 		hash_chip := poseidon.NewBLS12377Chip(api)
 		hash_values := []frontend.Variable{}
 
@@ -123,29 +165,41 @@ func (c *Test2Circuit[Field]) Define(api frontend.API) error {
 		hash_values = append(hash_values, c.B2.Limbs...)
 		hash_values = append(hash_values, c.N2)
 
-		hash_chip.HashNoPad(hash_values)
+		api.AssertIsEqual(hash_chip.HashNoPad(hash_values), c.H2)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	/*
 	 * Non native Mul
 	 */
-	{
+	err = profiling.Region(api, "Non native Mul 1", func() error {
 		fieldApi, err := emulated.NewField[Field](api)
 		if err != nil {
 			return err
 		}
-		fieldApi.Mul(&c.A1, &c.B1)
+		fieldApi.AssertIsEqual(fieldApi.Mul(&c.A1, &c.B1), &c.P1)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	/*
 	 * Non native Mul
 	 */
-	{
+	err = profiling.Region(api, "Non native Mul 2", func() error {
 		fieldApi, err := emulated.NewField[Field](api)
 		if err != nil {
 			return err
 		}
-		fieldApi.Mul(&c.A2, &c.B2)
+		fieldApi.AssertIsEqual(fieldApi.Mul(&c.A2, &c.B2), &c.P2)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,72 @@
+package circuit_gen
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/utils"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+)
+
+// solveAll solves every assignment against ccs, concurrently, and returns
+// the solutions in the same order.
+func solveAll(ccs constraint.ConstraintSystem, curve ecc.ID, assignments []frontend.Circuit) ([]any, error) {
+	solutions := make([]any, len(assignments))
+	errs := make([]error, len(assignments))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i := range assignments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			witness, err := frontend.NewWitness(assignments[i], curve.ScalarField())
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			solutions[i], errs[i] = ccs.Solve(witness)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("assignment %d: %w", i, err)
+		}
+	}
+	return solutions, nil
+}
+
+// writeAssignments writes the i-th assignment with write(i, path) to
+// export.AssignmentFile(i, n), then the assignments.json index.
+func writeAssignments(dir string, n int, write func(i int, path string) error) error {
+	for i := 0; i < n; i++ {
+		err := utils.WriteFileAtomic(filepath.Join(dir, export.AssignmentFile(i, n)), func(path string) error {
+			return write(i, path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(export.NewAssignmentIndex(n), "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(dir, "assignments.json"), func(path string) error {
+		return os.WriteFile(path, data, 0o644)
+	})
+}
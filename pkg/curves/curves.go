@@ -0,0 +1,41 @@
+package curves
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Default is the curve circuits are compiled for unless told otherwise.
+const Default = ecc.BLS12_377
+
+// Supported lists the curves whose scalar field circuits can be compiled
+// and exported for.
+var Supported = []ecc.ID{ecc.BLS12_377, ecc.BN254, ecc.BLS12_381, ecc.BW6_761}
+
+// Name returns the name of the curve as accepted by Parse, e.g. "bls12-377".
+func Name(curve ecc.ID) string {
+	return strings.ReplaceAll(curve.String(), "_", "-")
+}
+
+// Names returns the names of the supported curves.
+func Names() []string {
+	res := make([]string, len(Supported))
+	for i, curve := range Supported {
+		res[i] = Name(curve)
+	}
+	return res
+}
+
+// Parse returns the supported curve with the given name. Both "bls12-377"
+// and "bls12_377" spellings are accepted.
+func Parse(name string) (ecc.ID, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	for _, curve := range Supported {
+		if Name(curve) == normalized {
+			return curve, nil
+		}
+	}
+	return ecc.UNKNOWN, fmt.Errorf("unsupported curve %q, available: %s", name, strings.Join(Names(), ", "))
+}
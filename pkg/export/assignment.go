@@ -0,0 +1,90 @@
+// Package export defines the layout of the CBOR artifacts consumed by the
+// external provers. The r1cs.cbor and lookup.cbor of R1CS runs and the
+// assignments of BLS12-377 are written by gnark's export_utils, the others
+// by this package; ReadCBOR rejects a file which does not match its layout.
+package export
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Assignment is the layout of assignment.cbor, written by
+// export_utils.SerializeAssignment for BLS12-377 and by SerializeAssignment
+// for the other curves. Public starts with the constant one wire, Private
+// holds the secret wires followed by the internal ones, in the order of the
+// constraint system.
+type Assignment struct {
+	Public  []*big.Int `cbor:"public"`
+	Private []*big.Int `cbor:"private"`
+}
+
+// Values returns the full assignment, public wires first.
+func (a *Assignment) Values() []*big.Int {
+	res := make([]*big.Int, 0, len(a.Public)+len(a.Private))
+	res = append(res, a.Public...)
+	return append(res, a.Private...)
+}
+
+// WireValues returns the value of every wire of an R1CS solution, whatever
+// the curve it was solved over.
+func WireValues(solution any) ([]*big.Int, error) {
+	switch s := solution.(type) {
+	case *cs_bls12377.R1CSSolution:
+		return toBigInts(len(s.W), func(i int, v *big.Int) { s.W[i].BigInt(v) }), nil
+	case *cs_bn254.R1CSSolution:
+		return toBigInts(len(s.W), func(i int, v *big.Int) { s.W[i].BigInt(v) }), nil
+	case *cs_bls12381.R1CSSolution:
+		return toBigInts(len(s.W), func(i int, v *big.Int) { s.W[i].BigInt(v) }), nil
+	case *cs_bw6761.R1CSSolution:
+		return toBigInts(len(s.W), func(i int, v *big.Int) { s.W[i].BigInt(v) }), nil
+	default:
+		return nil, fmt.Errorf("unsupported solution type %T", solution)
+	}
+}
+
+func toBigInts(n int, get func(i int, v *big.Int)) []*big.Int {
+	res := make([]*big.Int, n)
+	for i := range res {
+		res[i] = new(big.Int)
+		get(i, res[i])
+	}
+	return res
+}
+
+// NewAssignment splits the wire values of sys into public and private ones.
+func NewAssignment(sys constraint.ConstraintSystem, values []*big.Int) (*Assignment, error) {
+	nbPublic := sys.GetNbPublicVariables()
+	if len(values) < nbPublic {
+		return nil, fmt.Errorf("solution has %d wires, expected at least %d public ones", len(values), nbPublic)
+	}
+	return &Assignment{
+		Public:  values[:nbPublic],
+		Private: values[nbPublic:],
+	}, nil
+}
+
+// SerializeAssignment writes the wire values of sys to path as CBOR.
+func SerializeAssignment(sys constraint.ConstraintSystem, values []*big.Int, path string) error {
+	assignment, err := NewAssignment(sys, values)
+	if err != nil {
+		return err
+	}
+	return writeCBOR(assignment, path)
+}
+
+func writeCBOR(v any, path string) error {
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
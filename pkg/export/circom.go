@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+)
+
+// The iden3 binary formats read by circom and snarkjs: a magic string, a
+// version and a list of sections, each prefixed with its type and size.
+// Field elements are little-endian, in regular (non-Montgomery) form, on a
+// multiple of 8 bytes.
+//
+// circom orders the wires as the constant one, the public outputs, the public
+// inputs, the private inputs and the internal wires, which is gnark's order
+// with no public output.
+const (
+	r1csVersion = 1
+	wtnsVersion = 2
+
+	r1csHeaderSection     = 1
+	r1csConstraintSection = 2
+	r1csWire2LabelSection = 3
+
+	wtnsHeaderSection = 1
+	wtnsDataSection   = 2
+)
+
+// fieldSize returns the number of bytes field elements of modulus are
+// written on.
+func fieldSize(modulus *big.Int) int {
+	return (modulus.BitLen() + 63) / 64 * 8
+}
+
+// WriteCircomR1CS writes r in the iden3 .r1cs format. Wire i has label i, see
+// WriteCircomSym for the label names.
+func WriteCircomR1CS(w io.Writer, r *R1CS, modulus *big.Int) error {
+	n8 := fieldSize(modulus)
+	nbWires := r.NbWires()
+
+	var header bytes.Buffer
+	le32(&header, uint32(n8))
+	header.Write(element(modulus, n8))
+	le32(&header, uint32(nbWires))
+	le32(&header, 0)                    // public outputs
+	le32(&header, uint32(r.NbPublic-1)) // public inputs, without the constant one
+	le32(&header, uint32(r.NbSecret))
+	le64(&header, uint64(nbWires)) // labels
+	le32(&header, uint32(len(r.A)))
+
+	var constraints bytes.Buffer
+	for i := range r.A {
+		for _, terms := range [][]Term{r.A[i], r.B[i], r.C[i]} {
+			le32(&constraints, uint32(len(terms)))
+			for _, t := range terms {
+				le32(&constraints, t.Wire)
+				constraints.Write(element(t.Coeff, n8))
+			}
+		}
+	}
+
+	var labels bytes.Buffer
+	for i := 0; i < nbWires; i++ {
+		le64(&labels, uint64(i))
+	}
+
+	return writeSections(w, "r1cs", r1csVersion, []section{
+		{r1csHeaderSection, header.Bytes()},
+		{r1csConstraintSection, constraints.Bytes()},
+		{r1csWire2LabelSection, labels.Bytes()},
+	})
+}
+
+// WriteCircomWtns writes the wire values of an assignment in the iden3 .wtns
+// format.
+func WriteCircomWtns(w io.Writer, values []*big.Int, modulus *big.Int) error {
+	n8 := fieldSize(modulus)
+
+	var header bytes.Buffer
+	le32(&header, uint32(n8))
+	header.Write(element(modulus, n8))
+	le32(&header, uint32(len(values)))
+
+	var data bytes.Buffer
+	for _, v := range values {
+		data.Write(element(v, n8))
+	}
+
+	return writeSections(w, "wtns", wtnsVersion, []section{
+		{wtnsHeaderSection, header.Bytes()},
+		{wtnsDataSection, data.Bytes()},
+	})
+}
+
+// WriteCircomSym writes the label names of the wires in circom's .sym
+// format: one "label,wire,component,name" line per wire. name returns the
+// name of a public or secret wire; the constant wire is named "one" and
+// internal wires after their index.
+func WriteCircomSym(w io.Writer, r *R1CS, name func(wire int) string) error {
+	bw := bufio.NewWriter(w)
+	for i := 0; i < r.NbWires(); i++ {
+		label := fmt.Sprintf("v%d", i)
+		switch {
+		case i == 0:
+			label = "one"
+		case i < r.NbPublic+r.NbSecret:
+			label = name(i)
+		}
+		fmt.Fprintf(bw, "%d,%d,0,main.%s\n", i, i, label)
+	}
+	return bw.Flush()
+}
+
+type section struct {
+	typ  uint32
+	data []byte
+}
+
+func writeSections(w io.Writer, magic string, version uint32, sections []section) error {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	le32(&buf, version)
+	le32(&buf, uint32(len(sections)))
+	for _, s := range sections {
+		le32(&buf, s.typ)
+		le64(&buf, uint64(len(s.data)))
+		buf.Write(s.data)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// element encodes v on n8 little-endian bytes.
+func element(v *big.Int, n8 int) []byte {
+	res := make([]byte, n8)
+	v.FillBytes(res)
+	for i, j := 0, n8-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+func le32(buf *bytes.Buffer, v uint32) {
+	buf.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+func le64(buf *bytes.Buffer, v uint64) {
+	buf.Write(binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// SerializeCircom writes the file at path with write.
+func SerializeCircom(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,193 @@
+package export_test
+
+import (
+	"errors"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/verify"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bls12-377"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/std/rangecheck/varuna"
+	"github.com/consensys/gnark/std/utils/export_utils"
+)
+
+// layoutCircuit has public, secret and internal wires, and range checks X
+// to 8 bits and Y to 16 bits.
+type layoutCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *layoutCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(api.Mul(c.X, c.Y), c.X), c.Z)
+	checker := rangecheck.New(api)
+	checker.Check(c.X, 8)
+	checker.Check(c.Y, 16)
+	return nil
+}
+
+// compile compiles layoutCircuit for BLS12-377 and solves it for X = 200,
+// Y = 60000. It also returns the range checks recorded by varuna.
+func compile(t *testing.T) (constraint.R1CS, *cs.R1CSSolution, *varuna.Lookup) {
+	t.Helper()
+	var builder frontend.Builder
+	newBuilder := func(field *big.Int, config frontend.CompileConfig) (frontend.Builder, error) {
+		b, err := r1cs.NewBuilder(field, config)
+		builder = b
+		return b, err
+	}
+	ccs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), newBuilder, &layoutCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := frontend.NewWitness(&layoutCircuit{X: 200, Y: 60000, Z: 200*60000 + 200}, ecc.BLS12_377.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := ccs.Solve(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ccs.(constraint.R1CS), solution.(*cs.R1CSSolution), varuna.GetLookupByBuilder(builder)
+}
+
+// TestR1CSLayout checks that the r1cs.cbor written by
+// export_utils.SerializeR1CS decodes to the constraints of gnark's
+// constraint system, and that they hold for its solution.
+func TestR1CSLayout(t *testing.T) {
+	sys, solution, _ := compile(t)
+	values, err := export.WireValues(solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "r1cs.cbor")
+	if err := export_utils.SerializeR1CS(sys, path); err != nil {
+		t.Fatal(err)
+	}
+	var actual export.R1CS
+	if err := export.ReadCBOR(path, &actual); err != nil {
+		t.Fatalf("r1cs written by export_utils: %v", err)
+	}
+
+	expected := export.NewR1CS(sys)
+	if actual.NbPublic != expected.NbPublic || actual.NbSecret != expected.NbSecret || actual.NbInternal != expected.NbInternal {
+		t.Fatalf("export_utils writes %d public, %d secret and %d internal wires, expected %d, %d and %d",
+			actual.NbPublic, actual.NbSecret, actual.NbInternal, expected.NbPublic, expected.NbSecret, expected.NbInternal)
+	}
+	for _, m := range []struct {
+		name             string
+		actual, expected [][]export.Term
+	}{{"A", actual.A, expected.A}, {"B", actual.B, expected.B}, {"C", actual.C, expected.C}} {
+		if len(m.actual) != len(m.expected) {
+			t.Fatalf("export_utils writes %d rows of %s, expected %d", len(m.actual), m.name, len(m.expected))
+		}
+		for i := range m.actual {
+			if !equalTerms(m.actual[i], m.expected[i]) {
+				t.Fatalf("export_utils writes row %d of %s as %v, expected %v", i, m.name, m.actual[i], m.expected[i])
+			}
+		}
+	}
+	if err := verify.R1CS(&actual, values, ecc.BLS12_377.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLookupLayout checks that the lookup.cbor written by
+// export_utils.SerializeLookup holds the range checks of layoutCircuit:
+// its solution passes them, and fails once X no longer fits in 8 bits.
+func TestLookupLayout(t *testing.T) {
+	sys, solution, lookup := compile(t)
+	values, err := export.WireValues(solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "lookup.cbor")
+	if err := export_utils.SerializeLookup(lookup, sys, path); err != nil {
+		t.Fatal(err)
+	}
+	var actual export.Lookup
+	if err := export.ReadCBOR(path, &actual); err != nil {
+		t.Fatalf("lookup written by export_utils: %v", err)
+	}
+	if len(actual.Tables) == 0 {
+		t.Fatal("export_utils writes no lookup table")
+	}
+	modulus := ecc.BLS12_377.ScalarField()
+	if err := verify.Lookup(&actual, values, modulus); err != nil {
+		t.Fatal(err)
+	}
+
+	// X is the first secret wire
+	values[sys.GetNbPublicVariables()] = big.NewInt(1 << 8)
+	var lookupErr *verify.LookupError
+	if err := verify.Lookup(&actual, values, modulus); !errors.As(err, &lookupErr) {
+		t.Fatalf("X = 2^8 passes the lookup, got %v", err)
+	}
+}
+
+// TestAssignmentLayout checks that export_utils.SerializeAssignment, which
+// writes the BLS12-377 assignments, and SerializeAssignment, which writes
+// those of the other curves, agree on the layout of Assignment.
+func TestAssignmentLayout(t *testing.T) {
+	sys, solution, _ := compile(t)
+	values, err := export.WireValues(solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	byExportUtils, byExport := filepath.Join(dir, "export_utils.cbor"), filepath.Join(dir, "export.cbor")
+	if err := export_utils.SerializeAssignment(sys, solution, byExportUtils); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.SerializeAssignment(sys, values, byExport); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected, actual export.Assignment
+	if err := export.ReadCBOR(byExport, &expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.ReadCBOR(byExportUtils, &actual); err != nil {
+		t.Fatalf("assignment written by export_utils: %v", err)
+	}
+	if len(expected.Public) != sys.GetNbPublicVariables() || len(expected.Values()) != len(values) {
+		t.Fatalf("SerializeAssignment writes %d public and %d private values, expected %d wires with %d public", len(expected.Public), len(expected.Private), len(values), sys.GetNbPublicVariables())
+	}
+	if len(actual.Public) != len(expected.Public) || !equal(actual.Values(), expected.Values()) {
+		t.Fatalf("export_utils writes public %v and private %v, SerializeAssignment public %v and private %v", actual.Public, actual.Private, expected.Public, expected.Private)
+	}
+}
+
+func equalTerms(a, b []export.Term) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Wire != b[i].Wire || a[i].Coeff.Cmp(b[i].Coeff) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func equal(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AssignmentIndex is the layout of assignments.json, which lists the
+// assignment files of a run, all solving the same constraint system.
+type AssignmentIndex struct {
+	Files []string `json:"files"`
+}
+
+// AssignmentFile returns the name of the i-th of n assignment files:
+// assignment.cbor when there is a single one, assignment_0000.cbor, ...
+// otherwise.
+func AssignmentFile(i, n int) string {
+	if n == 1 {
+		return "assignment.cbor"
+	}
+	return fmt.Sprintf("assignment_%04d.cbor", i)
+}
+
+// WitnessFile returns the name of the .wtns file of the i-th of n
+// assignments, named after its AssignmentFile.
+func WitnessFile(i, n int) string {
+	return strings.TrimSuffix(AssignmentFile(i, n), ".cbor") + ".wtns"
+}
+
+// NewAssignmentIndex returns the index of n assignment files.
+func NewAssignmentIndex(n int) *AssignmentIndex {
+	index := &AssignmentIndex{Files: make([]string, n)}
+	for i := range index.Files {
+		index.Files[i] = AssignmentFile(i, n)
+	}
+	return index
+}
+
+// ReadAssignmentIndex reads the assignments.json of a run directory. Runs
+// without one are assumed to have a single assignment.cbor.
+func ReadAssignmentIndex(dir string) (*AssignmentIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "assignments.json"))
+	if os.IsNotExist(err) {
+		return NewAssignmentIndex(1), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := &AssignmentIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
@@ -0,0 +1,286 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Formatter renders the artifacts as indented JSON, for debugging. Field
+// elements above half the modulus are printed as negative numbers, e.g. -1
+// rather than p-1.
+type Formatter struct {
+	Modulus *big.Int
+	// Hex prints field elements in hexadecimal rather than decimal.
+	Hex bool
+	// Names are the names of the wires, by index, see WireNames. Wires
+	// without a name are referred to as w[index].
+	Names []string
+}
+
+// Element formats a field element.
+func (f Formatter) Element(v *big.Int) string {
+	sign, abs := "", v
+	if f.Modulus != nil {
+		half := new(big.Int).Rsh(f.Modulus, 1)
+		if v.Cmp(half) > 0 {
+			sign, abs = "-", new(big.Int).Sub(f.Modulus, v)
+		}
+	}
+	if f.Hex {
+		return sign + "0x" + abs.Text(16)
+	}
+	return sign + abs.Text(10)
+}
+
+// Name returns the name of a wire.
+func (f Formatter) Name(wire uint32) string {
+	if int(wire) < len(f.Names) {
+		return f.Names[wire]
+	}
+	return fmt.Sprintf("w[%d]", wire)
+}
+
+// JSONTerm is a term of a linear combination, or a lookup entry.
+type JSONTerm struct {
+	Wire  uint32 `json:"wire"`
+	Name  string `json:"name"`
+	Coeff string `json:"coeff"`
+}
+
+// JSONConstraint is a constraint ⟨A,z⟩·⟨B,z⟩ = ⟨C,z⟩ of an R1CS, with its
+// linear combinations written out in Text.
+type JSONConstraint struct {
+	Index int        `json:"index"`
+	Text  string     `json:"text"`
+	A     []JSONTerm `json:"a"`
+	B     []JSONTerm `json:"b"`
+	C     []JSONTerm `json:"c"`
+}
+
+// JSONR1CS is the JSON rendering of an R1CS.
+type JSONR1CS struct {
+	Modulus     string           `json:"modulus"`
+	NbPublic    int              `json:"nb_public"`
+	NbSecret    int              `json:"nb_secret"`
+	NbInternal  int              `json:"nb_internal"`
+	Constraints []JSONConstraint `json:"constraints"`
+}
+
+// R1CS renders r.
+func (f Formatter) R1CS(r *R1CS) *JSONR1CS {
+	res := &JSONR1CS{
+		Modulus:     f.modulus(),
+		NbPublic:    r.NbPublic,
+		NbSecret:    r.NbSecret,
+		NbInternal:  r.NbInternal,
+		Constraints: make([]JSONConstraint, len(r.A)),
+	}
+	for i := range r.A {
+		res.Constraints[i] = JSONConstraint{
+			Index: i,
+			Text:  fmt.Sprintf("(%s) ⋅ (%s) = %s", f.linearExpression(r.A[i]), f.linearExpression(r.B[i]), f.linearExpression(r.C[i])),
+			A:     f.terms(r.A[i]),
+			B:     f.terms(r.B[i]),
+			C:     f.terms(r.C[i]),
+		}
+	}
+	return res
+}
+
+func (f Formatter) terms(terms []Term) []JSONTerm {
+	res := make([]JSONTerm, len(terms))
+	for i, t := range terms {
+		res[i] = JSONTerm{Wire: t.Wire, Name: f.Name(t.Wire), Coeff: f.Element(t.Coeff)}
+	}
+	return res
+}
+
+func (f Formatter) linearExpression(terms []Term) string {
+	if len(terms) == 0 {
+		return "0"
+	}
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = f.term(t.Coeff, f.Name(t.Wire))
+	}
+	return sum(parts)
+}
+
+// term formats coeff⋅name, omitting a coefficient of ±1.
+func (f Formatter) term(coeff *big.Int, name string) string {
+	c := f.Element(coeff)
+	switch c {
+	case "1", "0x1":
+		return name
+	case "-1", "-0x1":
+		return "-" + name
+	}
+	return c + "⋅" + name
+}
+
+// sum joins terms with + or -, depending on their sign.
+func sum(terms []string) string {
+	var sb strings.Builder
+	for i, t := range terms {
+		neg := strings.HasPrefix(t, "-")
+		switch {
+		case i == 0:
+			sb.WriteString(t)
+			continue
+		case neg:
+			sb.WriteString(" - ")
+			t = t[1:]
+		default:
+			sb.WriteString(" + ")
+		}
+		sb.WriteString(t)
+	}
+	return sb.String()
+}
+
+// JSONWire is a wire of a gate.
+type JSONWire struct {
+	Wire uint32 `json:"wire"`
+	Name string `json:"name"`
+}
+
+// JSONGate is a gate of a sparse R1CS, written out in Text.
+type JSONGate struct {
+	Index int      `json:"index"`
+	Text  string   `json:"text"`
+	L     JSONWire `json:"l"`
+	R     JSONWire `json:"r"`
+	O     JSONWire `json:"o"`
+	QL    string   `json:"ql"`
+	QR    string   `json:"qr"`
+	QO    string   `json:"qo"`
+	QM    string   `json:"qm"`
+	QC    string   `json:"qc"`
+}
+
+// JSONSparseR1CS is the JSON rendering of a sparse R1CS.
+type JSONSparseR1CS struct {
+	Modulus    string     `json:"modulus"`
+	NbPublic   int        `json:"nb_public"`
+	NbSecret   int        `json:"nb_secret"`
+	NbInternal int        `json:"nb_internal"`
+	Gates      []JSONGate `json:"gates"`
+}
+
+// SparseR1CS renders s.
+func (f Formatter) SparseR1CS(s *SparseR1CS) *JSONSparseR1CS {
+	res := &JSONSparseR1CS{
+		Modulus:    f.modulus(),
+		NbPublic:   s.NbPublic,
+		NbSecret:   s.NbSecret,
+		NbInternal: s.NbInternal,
+		Gates:      make([]JSONGate, len(s.Gates)),
+	}
+	for i, g := range s.Gates {
+		l, r, o := f.Name(g.L), f.Name(g.R), f.Name(g.O)
+		var parts []string
+		for _, t := range []struct {
+			coeff *big.Int
+			name  string
+		}{{g.QL, l}, {g.QR, r}, {g.QO, o}, {g.QM, l + "⋅" + r}} {
+			if t.coeff.Sign() != 0 {
+				parts = append(parts, f.term(t.coeff, t.name))
+			}
+		}
+		if g.QC.Sign() != 0 || len(parts) == 0 {
+			parts = append(parts, f.Element(g.QC))
+		}
+
+		res.Gates[i] = JSONGate{
+			Index: i,
+			Text:  sum(parts) + " = 0",
+			L:     JSONWire{Wire: g.L, Name: l},
+			R:     JSONWire{Wire: g.R, Name: r},
+			O:     JSONWire{Wire: g.O, Name: o},
+			QL:    f.Element(g.QL),
+			QR:    f.Element(g.QR),
+			QO:    f.Element(g.QO),
+			QM:    f.Element(g.QM),
+			QC:    f.Element(g.QC),
+		}
+	}
+	return res
+}
+
+// JSONLookupTable is the JSON rendering of a lookup table.
+type JSONLookupTable struct {
+	Bits    int        `json:"bits"`
+	Entries []JSONTerm `json:"entries"`
+}
+
+// Lookup renders l.
+func (f Formatter) Lookup(l *Lookup) []JSONLookupTable {
+	res := make([]JSONLookupTable, len(l.Tables))
+	for i, t := range l.Tables {
+		res[i] = JSONLookupTable{Bits: t.Bits, Entries: make([]JSONTerm, len(t.Entries))}
+		for j, e := range t.Entries {
+			res[i].Entries[j] = JSONTerm{Wire: e.Wire, Name: f.Name(e.Wire), Coeff: f.Element(e.Coeff)}
+		}
+	}
+	return res
+}
+
+// JSONValue is the value of a wire of an assignment.
+type JSONValue struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value"`
+}
+
+// JSONAssignment maps the index of every wire to its name, when known, and
+// value. It is encoded as a JSON object ordered by wire index.
+type JSONAssignment []JSONValue
+
+// MarshalJSON implements json.Marshaler.
+func (a JSONAssignment) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, v := range a {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(strconv.Quote(strconv.Itoa(i)) + ":")
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Assignment renders the wire values of an assignment.
+func (f Formatter) Assignment(values []*big.Int) JSONAssignment {
+	res := make(JSONAssignment, len(values))
+	for i, v := range values {
+		res[i] = JSONValue{Value: f.Element(v)}
+		if i < len(f.Names) {
+			res[i].Name = f.Names[i]
+		}
+	}
+	return res
+}
+
+// JSON returns v as indented JSON.
+func JSON(v any) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func (f Formatter) modulus() string {
+	if f.Modulus == nil {
+		return ""
+	}
+	if f.Hex {
+		return "0x" + f.Modulus.Text(16)
+	}
+	return f.Modulus.Text(10)
+}
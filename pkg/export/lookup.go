@@ -0,0 +1,87 @@
+package export
+
+import (
+	"fmt"
+	"gnark-circuit-gen/pkg/builders"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Lookup is the layout of lookup.cbor, written by
+// export_utils.SerializeLookup for R1CS and by SerializeLookup for sparse
+// constraint systems. Every table is the range [0, 2^Bits).
+type Lookup struct {
+	Tables []LookupTable `cbor:"tables"`
+}
+
+// LookupTable lists the entries which must lie in [0, 2^Bits).
+type LookupTable struct {
+	Bits    int           `cbor:"bits"`
+	Entries []LookupEntry `cbor:"entries"`
+}
+
+// LookupEntry is the value Coeff⋅w[Wire].
+type LookupEntry struct {
+	Wire  uint32   `cbor:"wire"`
+	Coeff *big.Int `cbor:"coeff"`
+}
+
+// RangeRecorder wraps a builder so that the range checks requested through
+// rangecheck.New are recorded instead of being arithmetised, the same way
+// varuna does for R1CS. The recorded checks are exported as lookup entries
+// and must be enforced by the prover.
+type RangeRecorder struct {
+	builders.Wrapper
+	checks map[int][]constraint.Term
+}
+
+// NewRangeRecorder wraps builder. It only supports builders whose canonical
+// variables are single terms, e.g. scs.NewBuilder. The recorder is handed to
+// the compiler through builders.Forward.
+func NewRangeRecorder(builder frontend.Builder) *RangeRecorder {
+	return &RangeRecorder{Wrapper: builders.Wrapper{Builder: builder}, checks: map[int][]constraint.Term{}}
+}
+
+// Check implements frontend.Rangechecker.
+func (r *RangeRecorder) Check(v frontend.Variable, bits int) {
+	if c, ok := r.Builder.ConstantValue(v); ok {
+		if c.Sign() < 0 || c.BitLen() > bits {
+			panic(fmt.Sprintf("constant %s does not fit in %d bits", c, bits))
+		}
+		return
+	}
+
+	t, ok := r.Builder.ToCanonicalVariable(v).(constraint.Term)
+	if !ok {
+		panic(fmt.Sprintf("range recorder: unsupported variable type %T", v))
+	}
+	r.checks[bits] = append(r.checks[bits], t)
+}
+
+// Lookup returns the recorded range checks, grouped by bit width, with the
+// coefficients resolved in sys.
+func (r *RangeRecorder) Lookup(sys constraint.ConstraintSystem) *Lookup {
+	widths := make([]int, 0, len(r.checks))
+	for bits := range r.checks {
+		widths = append(widths, bits)
+	}
+	sort.Ints(widths)
+
+	lookup := &Lookup{Tables: make([]LookupTable, 0, len(widths))}
+	for _, bits := range widths {
+		table := LookupTable{Bits: bits, Entries: make([]LookupEntry, len(r.checks[bits]))}
+		for i, t := range r.checks[bits] {
+			table.Entries[i] = LookupEntry{Wire: t.VID, Coeff: Coefficient(sys, t.CID)}
+		}
+		lookup.Tables = append(lookup.Tables, table)
+	}
+	return lookup
+}
+
+// SerializeLookup writes lookup to path as CBOR.
+func SerializeLookup(lookup *Lookup, path string) error {
+	return writeCBOR(lookup, path)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+var tVariable = reflect.TypeOf((*frontend.Variable)(nil)).Elem()
+
+// WireNames returns the names of the public and secret wires of circuit, in
+// the order gnark allocates them and starting with "one" for the constant
+// wire: e.g. "G1.X.Limbs[2]" where gnark itself uses "G1_X_Limbs_2".
+func WireNames(circuit frontend.Circuit) ([]string, error) {
+	var public, secret []string
+	_, err := schema.Walk(circuit, tVariable, func(f schema.LeafInfo, _ reflect.Value) error {
+		switch f.Visibility {
+		case schema.Public:
+			public = append(public, f.FullName())
+		case schema.Secret:
+			secret = append(secret, f.FullName())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// gnark joins every path element with "_", which is ambiguous; names
+	// mapped to by several paths are kept as is.
+	dotted := map[string]string{}
+	ambiguous := map[string]bool{}
+	walkLeaves(reflect.ValueOf(circuit), nil, func(path []string) {
+		name := strings.Join(path, "_")
+		if _, ok := dotted[name]; ok {
+			ambiguous[name] = true
+		}
+		dotted[name] = dottedName(path)
+	})
+
+	names := append([]string{"one"}, public...)
+	names = append(names, secret...)
+	for i, name := range names {
+		if d, ok := dotted[name]; ok && !ambiguous[name] {
+			names[i] = d
+		}
+	}
+	return names, nil
+}
+
+// walkLeaves calls leaf with the path of every frontend.Variable reachable
+// from v, the way schema.Walk names them: struct fields by their gnark tag or
+// field name, array and slice elements by their index.
+func walkLeaves(v reflect.Value, path []string, leaf func(path []string)) {
+	if v.Type() == tVariable {
+		leaf(path)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkLeaves(v.Elem(), path, leaf)
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkLeaves(v.Index(i), append(path[:len(path):len(path)], fmt.Sprint(i)), leaf)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.Anonymous {
+				walkLeaves(v.Field(i), path, leaf)
+				continue
+			}
+			tag, ok := sf.Tag.Lookup("gnark")
+			if ok && tag == "-" {
+				continue
+			}
+			name := sf.Name
+			if tagName, _, _ := strings.Cut(tag, ","); tagName != "" {
+				name = tagName
+			}
+			walkLeaves(v.Field(i), append(path[:len(path):len(path)], name), leaf)
+		}
+	}
+}
+
+// dottedName formats a path as a Go selector expression, "G1.X.Limbs[2]".
+func dottedName(path []string) string {
+	var sb strings.Builder
+	for i, p := range path {
+		switch {
+		case p[0] >= '0' && p[0] <= '9':
+			fmt.Fprintf(&sb, "[%s]", p)
+		case i > 0:
+			sb.WriteString("." + p)
+		default:
+			sb.WriteString(p)
+		}
+	}
+	return sb.String()
+}
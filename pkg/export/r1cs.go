@@ -0,0 +1,77 @@
+package export
+
+import (
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// R1CS is the layout of r1cs.cbor, written by export_utils.SerializeR1CS.
+// Row i of A, B and C holds the sparse linear combinations of constraint i,
+// ⟨A_i,z⟩·⟨B_i,z⟩ = ⟨C_i,z⟩, where z is the assignment with the constant
+// one wire at index 0.
+type R1CS struct {
+	NbPublic   int      `cbor:"nb_public"`
+	NbSecret   int      `cbor:"nb_secret"`
+	NbInternal int      `cbor:"nb_internal"`
+	A          [][]Term `cbor:"a"`
+	B          [][]Term `cbor:"b"`
+	C          [][]Term `cbor:"c"`
+}
+
+// Term is the value Coeff⋅z[Wire].
+type Term struct {
+	Wire  uint32   `cbor:"wire"`
+	Coeff *big.Int `cbor:"coeff"`
+}
+
+// NbWires returns the length of the assignment of the system.
+func (r *R1CS) NbWires() int {
+	return r.NbPublic + r.NbSecret + r.NbInternal
+}
+
+// NewR1CS converts sys to its exported layout.
+func NewR1CS(sys constraint.R1CS) *R1CS {
+	res := &R1CS{
+		NbPublic:   sys.GetNbPublicVariables(),
+		NbSecret:   sys.GetNbSecretVariables(),
+		NbInternal: sys.GetNbInternalVariables(),
+	}
+
+	for _, r1c := range sys.GetR1Cs() {
+		res.A = append(res.A, newTerms(sys, r1c.L))
+		res.B = append(res.B, newTerms(sys, r1c.R))
+		res.C = append(res.C, newTerms(sys, r1c.O))
+	}
+	return res
+}
+
+func newTerms(sys constraint.ConstraintSystem, l constraint.LinearExpression) []Term {
+	res := make([]Term, len(l))
+	for i, t := range l {
+		res[i] = Term{Wire: t.VID, Coeff: Coefficient(sys, t.CID)}
+	}
+	return res
+}
+
+// strict rejects the keys the layouts do not know about, which would
+// otherwise be dropped silently and decode as empty rows or tables.
+var strict = func() cbor.DecMode {
+	mode, err := cbor.DecOptions{ExtraReturnErrors: cbor.ExtraDecErrorUnknownField}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// ReadCBOR decodes the CBOR file at path into v, failing on keys which are
+// not part of the layout of v.
+func ReadCBOR(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return strict.Unmarshal(data, v)
+}
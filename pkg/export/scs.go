@@ -0,0 +1,130 @@
+package export
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+)
+
+// SparseR1CS is the layout of scs.cbor. Wires are indexed as in the
+// assignment: public wires first, then secret and internal ones. Unlike
+// R1CS there is no constant one wire.
+type SparseR1CS struct {
+	NbPublic   int    `cbor:"nb_public"`
+	NbSecret   int    `cbor:"nb_secret"`
+	NbInternal int    `cbor:"nb_internal"`
+	Gates      []Gate `cbor:"gates"`
+}
+
+// Gate enforces qL⋅l + qR⋅r + qO⋅o + qM⋅(l⋅r) + qC == 0 on the wires l, r
+// and o.
+type Gate struct {
+	L  uint32   `cbor:"l"`
+	R  uint32   `cbor:"r"`
+	O  uint32   `cbor:"o"`
+	QL *big.Int `cbor:"ql"`
+	QR *big.Int `cbor:"qr"`
+	QO *big.Int `cbor:"qo"`
+	QM *big.Int `cbor:"qm"`
+	QC *big.Int `cbor:"qc"`
+}
+
+// Coefficient returns the value of the coefficient cID of sys, reduced in
+// the scalar field.
+func Coefficient(sys constraint.ConstraintSystem, cID uint32) *big.Int {
+	c, ok := new(big.Int).SetString(sys.CoeffToString(int(cID)), 10)
+	if !ok {
+		panic("invalid coefficient " + sys.CoeffToString(int(cID)))
+	}
+	return c.Mod(c, sys.Field())
+}
+
+// NewSparseR1CS converts sys to its exported layout.
+func NewSparseR1CS(sys constraint.SparseR1CS) *SparseR1CS {
+	res := &SparseR1CS{
+		NbPublic:   sys.GetNbPublicVariables(),
+		NbSecret:   sys.GetNbSecretVariables(),
+		NbInternal: sys.GetNbInternalVariables(),
+		Gates:      make([]Gate, 0, sys.GetNbConstraints()),
+	}
+
+	it := sys.GetSparseR1CIterator()
+	for c := it.Next(); c != nil; c = it.Next() {
+		res.Gates = append(res.Gates, Gate{
+			L:  c.XA,
+			R:  c.XB,
+			O:  c.XC,
+			QL: Coefficient(sys, c.QL),
+			QR: Coefficient(sys, c.QR),
+			QO: Coefficient(sys, c.QO),
+			QM: Coefficient(sys, c.QM),
+			QC: Coefficient(sys, c.QC),
+		})
+	}
+	return res
+}
+
+// SerializeSparseR1CS writes the gates of sys to path as CBOR.
+func SerializeSparseR1CS(sys constraint.SparseR1CS, path string) error {
+	return writeCBOR(NewSparseR1CS(sys), path)
+}
+
+// SparseWireValues recovers the value of every wire of sys from a
+// SparseR1CS solution, whatever the curve it was solved over. gnark only
+// keeps the values of the l, r and o columns, so an error is returned for
+// a wire which is neither public nor part of any gate, e.g. one only range
+// checked by a RangeRecorder, rather than exporting a wrong value for it.
+func SparseWireValues(sys constraint.SparseR1CS, solution any) ([]*big.Int, error) {
+	var l, r, o []*big.Int
+	switch s := solution.(type) {
+	case *cs_bls12377.SparseR1CSSolution:
+		l = toBigInts(len(s.L), func(i int, v *big.Int) { s.L[i].BigInt(v) })
+		r = toBigInts(len(s.R), func(i int, v *big.Int) { s.R[i].BigInt(v) })
+		o = toBigInts(len(s.O), func(i int, v *big.Int) { s.O[i].BigInt(v) })
+	case *cs_bn254.SparseR1CSSolution:
+		l = toBigInts(len(s.L), func(i int, v *big.Int) { s.L[i].BigInt(v) })
+		r = toBigInts(len(s.R), func(i int, v *big.Int) { s.R[i].BigInt(v) })
+		o = toBigInts(len(s.O), func(i int, v *big.Int) { s.O[i].BigInt(v) })
+	case *cs_bls12381.SparseR1CSSolution:
+		l = toBigInts(len(s.L), func(i int, v *big.Int) { s.L[i].BigInt(v) })
+		r = toBigInts(len(s.R), func(i int, v *big.Int) { s.R[i].BigInt(v) })
+		o = toBigInts(len(s.O), func(i int, v *big.Int) { s.O[i].BigInt(v) })
+	case *cs_bw6761.SparseR1CSSolution:
+		l = toBigInts(len(s.L), func(i int, v *big.Int) { s.L[i].BigInt(v) })
+		r = toBigInts(len(s.R), func(i int, v *big.Int) { s.R[i].BigInt(v) })
+		o = toBigInts(len(s.O), func(i int, v *big.Int) { s.O[i].BigInt(v) })
+	default:
+		return nil, fmt.Errorf("unsupported solution type %T", solution)
+	}
+
+	nbPublic := sys.GetNbPublicVariables()
+	nbWires := nbPublic + sys.GetNbSecretVariables() + sys.GetNbInternalVariables()
+	if len(l) < nbPublic+sys.GetNbConstraints() {
+		return nil, fmt.Errorf("solution has %d rows, expected at least %d", len(l), nbPublic+sys.GetNbConstraints())
+	}
+
+	values := make([]*big.Int, nbWires)
+
+	// the first rows of the solution hold the public inputs in l, the gates
+	// follow in the order of the constraint system
+	for i := 0; i < nbPublic; i++ {
+		values[i] = l[i]
+	}
+	it := sys.GetSparseR1CIterator()
+	for row, c := nbPublic, it.Next(); c != nil; row, c = row+1, it.Next() {
+		values[c.XA] = l[row]
+		values[c.XB] = r[row]
+		values[c.XC] = o[row]
+	}
+	for i, v := range values {
+		if v == nil {
+			return nil, fmt.Errorf("wire %d (%s) appears in no gate, its value is not part of the solution", i, sys.VariableToString(i))
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,213 @@
+// Package fields provides emulated fields whose parameters are chosen at run
+// time, e.g. read from a spec file or derived from CLI flags.
+//
+// gnark's emulated.Field is parametrised by a type whose zero value reports
+// the modulus and the limb decomposition. This package defines a fixed pool
+// of such types, each bound to the parameters of the first Register call
+// which claims it. Bindings are never released, so a process can use at
+// most MaxFields distinct parametrisations.
+package fields
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// MaxFields is the number of distinct parametrisations a process can
+// register.
+const MaxFields = 8
+
+// Params describes an emulated field.
+type Params struct {
+	Modulus     *big.Int
+	NbLimbs     uint
+	BitsPerLimb uint
+}
+
+// NewParams returns the parameters of the field of the given modulus with
+// limbs of bitsPerLimb bits, and as many limbs as needed.
+func NewParams(modulus *big.Int, bitsPerLimb uint) Params {
+	return Params{
+		Modulus:     modulus,
+		NbLimbs:     (uint(modulus.BitLen()) + bitsPerLimb - 1) / bitsPerLimb,
+		BitsPerLimb: bitsPerLimb,
+	}
+}
+
+// Validate checks the parameters the way emulated.NewField does, minus the
+// constraints depending on the native field.
+func (p Params) Validate() error {
+	if p.Modulus == nil || p.Modulus.Cmp(big.NewInt(1)) < 1 {
+		return fmt.Errorf("modulus must be at least 2")
+	}
+	if p.BitsPerLimb < 3 {
+		return fmt.Errorf("limbs must have at least 3 bits, got %d", p.BitsPerLimb)
+	}
+	if expected := NewParams(p.Modulus, p.BitsPerLimb).NbLimbs; p.NbLimbs != expected {
+		return fmt.Errorf("a %d-bit modulus takes %d limbs of %d bits, got %d limbs", p.Modulus.BitLen(), expected, p.BitsPerLimb, p.NbLimbs)
+	}
+	return nil
+}
+
+func (p Params) equal(q Params) bool {
+	return p.Modulus.Cmp(q.Modulus) == 0 && p.NbLimbs == q.NbLimbs && p.BitsPerLimb == q.BitsPerLimb
+}
+
+// Field is a registered emulated field.
+type Field struct {
+	Params
+	slot int
+}
+
+var (
+	lock  sync.RWMutex
+	slots []Params
+	prime []bool
+)
+
+// Register binds p to one of the pool types and returns the field. Calls
+// with equal parameters return the same field.
+func Register(p Params) (*Field, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	for i, q := range slots {
+		if q.equal(p) {
+			return &Field{Params: q, slot: i}, nil
+		}
+	}
+	if len(slots) == MaxFields {
+		return nil, fmt.Errorf("at most %d distinct emulated fields can be used", MaxFields)
+	}
+	p.Modulus = new(big.Int).Set(p.Modulus)
+	slots = append(slots, p)
+	prime = append(prime, p.Modulus.ProbablyPrime(20))
+	return &Field{Params: p, slot: len(slots) - 1}, nil
+}
+
+func params(slot int) Params {
+	lock.RLock()
+	defer lock.RUnlock()
+	return slots[slot]
+}
+
+func isPrime(slot int) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return prime[slot]
+}
+
+// Limbs decomposes v, reduced modulo the field, into its limbs, least
+// significant first, as emulated.ValueOf does.
+func (f *Field) Limbs(v *big.Int) []frontend.Variable {
+	r := new(big.Int).Mod(v, f.Modulus)
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), f.BitsPerLimb), big.NewInt(1))
+
+	limbs := make([]frontend.Variable, f.NbLimbs)
+	for i := range limbs {
+		limbs[i] = new(big.Int).And(r, mask)
+		r.Rsh(r, f.BitsPerLimb)
+	}
+	return limbs
+}
+
+// NewAPI returns the arithmetic of the field in the circuit of api.
+func (f *Field) NewAPI(api frontend.API) (API, error) {
+	switch f.slot {
+	case 0:
+		return newAPI[F0](api)
+	case 1:
+		return newAPI[F1](api)
+	case 2:
+		return newAPI[F2](api)
+	case 3:
+		return newAPI[F3](api)
+	case 4:
+		return newAPI[F4](api)
+	case 5:
+		return newAPI[F5](api)
+	case 6:
+		return newAPI[F6](api)
+	case 7:
+		return newAPI[F7](api)
+	default:
+		return nil, fmt.Errorf("invalid field slot %d", f.slot)
+	}
+}
+
+// Element is an *emulated.Element of the field of the API which created it.
+type Element any
+
+// API is the emulated arithmetic of a field, independent of its parameter
+// type.
+type API interface {
+	// NewElement returns the element whose limbs are the given circuit
+	// inputs. As for the emulated elements of a circuit struct, the limbs are
+	// range checked when first used.
+	NewElement(limbs []frontend.Variable) Element
+	// Limbs returns the limbs of a, reduced.
+	Limbs(a Element) []frontend.Variable
+	Add(a, b Element) Element
+	Sub(a, b Element) Element
+	Mul(a, b Element) Element
+	MulMod(a, b Element) Element
+	Div(a, b Element) Element
+	Reduce(a Element) Element
+	AssertIsEqual(a, b Element)
+}
+
+type fieldAPI[T emulated.FieldParams] struct {
+	f *emulated.Field[T]
+}
+
+func newAPI[T emulated.FieldParams](api frontend.API) (API, error) {
+	f, err := emulated.NewField[T](api)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldAPI[T]{f: f}, nil
+}
+
+func (a *fieldAPI[T]) NewElement(limbs []frontend.Variable) Element {
+	return &emulated.Element[T]{Limbs: limbs}
+}
+
+func (a *fieldAPI[T]) Limbs(x Element) []frontend.Variable {
+	return a.f.Reduce(x.(*emulated.Element[T])).Limbs
+}
+
+func (a *fieldAPI[T]) Add(x, y Element) Element {
+	return a.f.Add(x.(*emulated.Element[T]), y.(*emulated.Element[T]))
+}
+
+func (a *fieldAPI[T]) Sub(x, y Element) Element {
+	return a.f.Sub(x.(*emulated.Element[T]), y.(*emulated.Element[T]))
+}
+
+func (a *fieldAPI[T]) Mul(x, y Element) Element {
+	return a.f.Mul(x.(*emulated.Element[T]), y.(*emulated.Element[T]))
+}
+
+func (a *fieldAPI[T]) MulMod(x, y Element) Element {
+	return a.f.MulMod(x.(*emulated.Element[T]), y.(*emulated.Element[T]))
+}
+
+func (a *fieldAPI[T]) Div(x, y Element) Element {
+	return a.f.Div(x.(*emulated.Element[T]), y.(*emulated.Element[T]))
+}
+
+func (a *fieldAPI[T]) Reduce(x Element) Element {
+	return a.f.Reduce(x.(*emulated.Element[T]))
+}
+
+func (a *fieldAPI[T]) AssertIsEqual(x, y Element) {
+	a.f.AssertIsEqual(x.(*emulated.Element[T]), y.(*emulated.Element[T]))
+}
@@ -0,0 +1,61 @@
+package fields
+
+import "math/big"
+
+// F0, ..., F7 are the emulated.FieldParams of the pool. Their methods report
+// the parameters bound by Register, and panic when called on an unbound one.
+type F0 struct{}
+
+func (F0) NbLimbs() uint     { return params(0).NbLimbs }
+func (F0) BitsPerLimb() uint { return params(0).BitsPerLimb }
+func (F0) IsPrime() bool     { return isPrime(0) }
+func (F0) Modulus() *big.Int { return new(big.Int).Set(params(0).Modulus) }
+
+type F1 struct{}
+
+func (F1) NbLimbs() uint     { return params(1).NbLimbs }
+func (F1) BitsPerLimb() uint { return params(1).BitsPerLimb }
+func (F1) IsPrime() bool     { return isPrime(1) }
+func (F1) Modulus() *big.Int { return new(big.Int).Set(params(1).Modulus) }
+
+type F2 struct{}
+
+func (F2) NbLimbs() uint     { return params(2).NbLimbs }
+func (F2) BitsPerLimb() uint { return params(2).BitsPerLimb }
+func (F2) IsPrime() bool     { return isPrime(2) }
+func (F2) Modulus() *big.Int { return new(big.Int).Set(params(2).Modulus) }
+
+type F3 struct{}
+
+func (F3) NbLimbs() uint     { return params(3).NbLimbs }
+func (F3) BitsPerLimb() uint { return params(3).BitsPerLimb }
+func (F3) IsPrime() bool     { return isPrime(3) }
+func (F3) Modulus() *big.Int { return new(big.Int).Set(params(3).Modulus) }
+
+type F4 struct{}
+
+func (F4) NbLimbs() uint     { return params(4).NbLimbs }
+func (F4) BitsPerLimb() uint { return params(4).BitsPerLimb }
+func (F4) IsPrime() bool     { return isPrime(4) }
+func (F4) Modulus() *big.Int { return new(big.Int).Set(params(4).Modulus) }
+
+type F5 struct{}
+
+func (F5) NbLimbs() uint     { return params(5).NbLimbs }
+func (F5) BitsPerLimb() uint { return params(5).BitsPerLimb }
+func (F5) IsPrime() bool     { return isPrime(5) }
+func (F5) Modulus() *big.Int { return new(big.Int).Set(params(5).Modulus) }
+
+type F6 struct{}
+
+func (F6) NbLimbs() uint     { return params(6).NbLimbs }
+func (F6) BitsPerLimb() uint { return params(6).BitsPerLimb }
+func (F6) IsPrime() bool     { return isPrime(6) }
+func (F6) Modulus() *big.Int { return new(big.Int).Set(params(6).Modulus) }
+
+type F7 struct{}
+
+func (F7) NbLimbs() uint     { return params(7).NbLimbs }
+func (F7) BitsPerLimb() uint { return params(7).BitsPerLimb }
+func (F7) IsPrime() bool     { return isPrime(7) }
+func (F7) Modulus() *big.Int { return new(big.Int).Set(params(7).Modulus) }
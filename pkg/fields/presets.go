@@ -0,0 +1,49 @@
+package fields
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+func paramsOf[T emulated.FieldParams]() Params {
+	var p T
+	return Params{Modulus: p.Modulus(), NbLimbs: p.NbLimbs(), BitsPerLimb: p.BitsPerLimb()}
+}
+
+// Presets are the parameters of the emulated fields predefined by gnark.
+var Presets = map[string]Params{
+	"goldilocks":   paramsOf[emulated.Goldilocks](),
+	"secp256k1-fp": paramsOf[emulated.Secp256k1Fp](),
+	"secp256k1-fr": paramsOf[emulated.Secp256k1Fr](),
+	"bn254-fp":     paramsOf[emulated.BN254Fp](),
+	"bn254-fr":     paramsOf[emulated.BN254Fr](),
+	"bls12-377-fp": paramsOf[emulated.BLS12377Fp](),
+	"bls12-381-fp": paramsOf[emulated.BLS12381Fp](),
+	"bls12-381-fr": paramsOf[emulated.BLS12381Fr](),
+	"p256-fp":      paramsOf[emulated.P256Fp](),
+	"p256-fr":      paramsOf[emulated.P256Fr](),
+	"p384-fp":      paramsOf[emulated.P384Fp](),
+	"p384-fr":      paramsOf[emulated.P384Fr](),
+}
+
+// ParseModulus returns the modulus of a preset, or parses a decimal or
+// 0x-prefixed hexadecimal integer.
+func ParseModulus(s string) (*big.Int, error) {
+	if p, ok := Presets[s]; ok {
+		return new(big.Int).Set(p.Modulus), nil
+	}
+	m, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		names := make([]string, 0, len(Presets))
+		for name := range Presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("invalid modulus %q, expected an integer or one of %s", s, strings.Join(names, ", "))
+	}
+	return m, nil
+}
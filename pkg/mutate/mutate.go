@@ -0,0 +1,279 @@
+// Package mutate derives deliberately invalid assignments from a valid one,
+// to check that downstream provers and verifiers reject them.
+package mutate
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/verify"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// Kind identifies a mutation strategy.
+type Kind string
+
+const (
+	// FlipPublicInput adds one to a public input.
+	FlipPublicInput Kind = "flip-public-input"
+	// PerturbInternalWire adds one to an internal wire.
+	PerturbInternalWire Kind = "perturb-internal-wire"
+	// OutOfRangeLimb sets the wire of a lookup entry so that the entry
+	// equals 2^bits, just outside of its table.
+	OutOfRangeLimb Kind = "out-of-range-limb"
+	// SwapEmulatedLimbs swaps two consecutive limbs of an emulated element
+	// given as public input.
+	SwapEmulatedLimbs Kind = "swap-emulated-limbs"
+)
+
+// Kinds lists every mutation strategy.
+var Kinds = []Kind{FlipPublicInput, PerturbInternalWire, OutOfRangeLimb, SwapEmulatedLimbs}
+
+// maxAttempts bounds the number of random wires tried before giving up on a
+// mutation none of the constraints and lookup entries detect.
+const maxAttempts = 64
+
+// ErrUndetected is returned when no mutation of the requested kind could be
+// found which breaks a constraint or a lookup entry.
+var ErrUndetected = errors.New("mutation not detected by any constraint or lookup entry")
+
+// Input is the valid assignment to mutate together with the constraint
+// system it satisfies.
+type Input struct {
+	R1CS    *export.R1CS
+	Lookup  *export.Lookup
+	Values  []*big.Int
+	Modulus *big.Int
+	// Names returns the name of a public or secret wire.
+	Names func(wire int) string
+	Rand  io.Reader
+}
+
+// LookupRef locates a lookup entry.
+type LookupRef struct {
+	Table int `json:"table"`
+	Entry int `json:"entry"`
+}
+
+// Mutation is an invalid assignment and what it is expected to break.
+type Mutation struct {
+	Kind        Kind   `json:"kind"`
+	Description string `json:"description"`
+	Wires       []int  `json:"wires"`
+	// ExpectedConstraint is the index of the first constraint violated by
+	// the mutated assignment, -1 if they all hold.
+	ExpectedConstraint int `json:"expected_constraint"`
+	// ExpectedLookup is the first lookup entry out of its table, if any.
+	ExpectedLookup *LookupRef `json:"expected_lookup,omitempty"`
+
+	Values []*big.Int `json:"-"`
+}
+
+// New returns a mutation of the given kind which is detected by at least one
+// constraint or lookup entry.
+func New(kind Kind, in Input) (*Mutation, error) {
+	var try func(in Input) (*Mutation, error)
+	switch kind {
+	case FlipPublicInput:
+		try = flipPublicInput
+	case PerturbInternalWire:
+		try = perturbInternalWire
+	case OutOfRangeLimb:
+		try = outOfRangeLimb
+	case SwapEmulatedLimbs:
+		try = swapEmulatedLimbs
+	default:
+		return nil, fmt.Errorf("unknown mutation %q", kind)
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		m, err := try(in)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.expect(in); err != nil {
+			return nil, err
+		}
+		if m.ExpectedConstraint >= 0 || m.ExpectedLookup != nil {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", kind, ErrUndetected)
+}
+
+// expect records the first constraint and lookup entry the mutation breaks.
+func (m *Mutation) expect(in Input) error {
+	m.ExpectedConstraint = -1
+	m.ExpectedLookup = nil
+
+	var cErr *verify.ConstraintError
+	err := verify.R1CS(in.R1CS, m.Values, in.Modulus)
+	if errors.As(err, &cErr) {
+		m.ExpectedConstraint = cErr.Index
+	} else if err != nil {
+		return err
+	}
+
+	if in.Lookup == nil {
+		return nil
+	}
+	var lErr *verify.LookupError
+	err = verify.Lookup(in.Lookup, m.Values, in.Modulus)
+	if errors.As(err, &lErr) {
+		m.ExpectedLookup = &LookupRef{Table: lErr.Table, Entry: lErr.Index}
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func copyValues(values []*big.Int) []*big.Int {
+	res := make([]*big.Int, len(values))
+	for i, v := range values {
+		res[i] = new(big.Int).Set(v)
+	}
+	return res
+}
+
+// randIndex returns a random integer in [lo, hi).
+func randIndex(r io.Reader, lo, hi int) (int, error) {
+	if hi <= lo {
+		return 0, errors.New("empty range")
+	}
+	n, err := rand.Int(r, big.NewInt(int64(hi-lo)))
+	if err != nil {
+		return 0, err
+	}
+	return lo + int(n.Int64()), nil
+}
+
+func addOne(in Input, kind Kind, what string, lo, hi int) (*Mutation, error) {
+	if hi <= lo {
+		return nil, fmt.Errorf("%s: the system has no %s", kind, what)
+	}
+	wire, err := randIndex(in.Rand, lo, hi)
+	if err != nil {
+		return nil, err
+	}
+
+	values := copyValues(in.Values)
+	values[wire].Add(values[wire], big.NewInt(1)).Mod(values[wire], in.Modulus)
+
+	return &Mutation{
+		Kind:        kind,
+		Description: fmt.Sprintf("added 1 to %s w[%d]%s", what, wire, wireName(in, wire)),
+		Wires:       []int{wire},
+		Values:      values,
+	}, nil
+}
+
+func flipPublicInput(in Input) (*Mutation, error) {
+	// wire 0 is the constant one
+	return addOne(in, FlipPublicInput, "public input", 1, in.R1CS.NbPublic)
+}
+
+func perturbInternalWire(in Input) (*Mutation, error) {
+	firstInternal := in.R1CS.NbPublic + in.R1CS.NbSecret
+	return addOne(in, PerturbInternalWire, "internal wire", firstInternal, in.R1CS.NbWires())
+}
+
+func outOfRangeLimb(in Input) (*Mutation, error) {
+	if in.Lookup == nil {
+		return nil, fmt.Errorf("%s: no lookup", OutOfRangeLimb)
+	}
+	nbEntries := 0
+	for _, t := range in.Lookup.Tables {
+		nbEntries += len(t.Entries)
+	}
+	if nbEntries == 0 {
+		return nil, fmt.Errorf("%s: the lookup has no entries", OutOfRangeLimb)
+	}
+
+	idx, err := randIndex(in.Rand, 0, nbEntries)
+	if err != nil {
+		return nil, err
+	}
+	table := 0
+	for idx >= len(in.Lookup.Tables[table].Entries) {
+		idx -= len(in.Lookup.Tables[table].Entries)
+		table++
+	}
+	bits := in.Lookup.Tables[table].Bits
+	entry := in.Lookup.Tables[table].Entries[idx]
+
+	// w = 2^bits / coeff, so that the entry coeff⋅w is 2^bits
+	inv := new(big.Int).ModInverse(entry.Coeff, in.Modulus)
+	if inv == nil {
+		return nil, fmt.Errorf("%s: lookup entry with zero coefficient", OutOfRangeLimb)
+	}
+	values := copyValues(in.Values)
+	w := values[entry.Wire]
+	w.Lsh(big.NewInt(1), uint(bits)).Mul(w, inv).Mod(w, in.Modulus)
+
+	return &Mutation{
+		Kind:        OutOfRangeLimb,
+		Description: fmt.Sprintf("set w[%d]%s of entry %d of table %d to 2^%d", entry.Wire, wireName(in, int(entry.Wire)), idx, table, bits),
+		Wires:       []int{int(entry.Wire)},
+		Values:      values,
+	}, nil
+}
+
+// limbName matches the names gnark gives to the limbs of an emulated
+// element, e.g. "G1_X_Limbs_2".
+var limbName = regexp.MustCompile(`^(.*)_Limbs_(\d+)$`)
+
+func swapEmulatedLimbs(in Input) (*Mutation, error) {
+	if in.Names == nil {
+		return nil, fmt.Errorf("%s: wire names are unknown", SwapEmulatedLimbs)
+	}
+
+	// pairs of consecutive limbs with distinct values
+	byName := map[string]int{}
+	for wire := 1; wire < in.R1CS.NbPublic+in.R1CS.NbSecret; wire++ {
+		byName[in.Names(wire)] = wire
+	}
+	var pairs [][2]int
+	for wire := 1; wire < in.R1CS.NbPublic+in.R1CS.NbSecret; wire++ {
+		m := limbName.FindStringSubmatch(in.Names(wire))
+		if m == nil {
+			continue
+		}
+		k, _ := strconv.Atoi(m[2])
+		next, ok := byName[fmt.Sprintf("%s_Limbs_%d", m[1], k+1)]
+		if ok && in.Values[wire].Cmp(in.Values[next]) != 0 {
+			pairs = append(pairs, [2]int{wire, next})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("%s: no emulated element among the inputs", SwapEmulatedLimbs)
+	}
+
+	i, err := randIndex(in.Rand, 0, len(pairs))
+	if err != nil {
+		return nil, err
+	}
+	a, b := pairs[i][0], pairs[i][1]
+
+	values := copyValues(in.Values)
+	values[a], values[b] = values[b], values[a]
+
+	return &Mutation{
+		Kind:        SwapEmulatedLimbs,
+		Description: fmt.Sprintf("swapped w[%d]%s and w[%d]%s", a, wireName(in, a), b, wireName(in, b)),
+		Wires:       []int{a, b},
+		Values:      values,
+	}, nil
+}
+
+// wireName returns " (name)" for public and secret wires, internal ones are
+// anonymous.
+func wireName(in Input, wire int) string {
+	if in.Names == nil || wire >= in.R1CS.NbPublic+in.R1CS.NbSecret {
+		return ""
+	}
+	return " (" + in.Names(wire) + ")"
+}
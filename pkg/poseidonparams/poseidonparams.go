@@ -0,0 +1,102 @@
+// Package poseidonparams writes optimized Poseidon parameters as Go source,
+// in the layout of common/poseidon/bls12377_constants.go, and checks
+// existing files against them.
+package poseidonparams
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"gnark-circuit-gen/common/poseidon"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// WriteGo writes the Go source of package pkg declaring the round numbers
+// <NAME>_FULL_ROUNDS and <NAME>_PARTIAL_ROUNDS and the optimized constants
+// of params, set in init: cConstants<name>, sConstants<name>,
+// mMatrix<name> and pMatrix<name>.
+func WriteGo(w io.Writer, params *poseidon.Params, pkg, name string) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	o := params.Optimized
+	if o == nil {
+		return fmt.Errorf("poseidon parameters are not optimized")
+	}
+	c, s, m, p := "cConstants"+name, "sConstants"+name, "mMatrix"+name, "pMatrix"+name
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "package %s\n\n", pkg)
+	fmt.Fprintf(b, "import (\n\t\"math/big\"\n)\n\n")
+	fmt.Fprintf(b, "const %s_FULL_ROUNDS int = %d\n", strings.ToUpper(name), params.FullRounds)
+	fmt.Fprintf(b, "const %s_PARTIAL_ROUNDS int = %d\n\n", strings.ToUpper(name), params.PartialRounds)
+	fmt.Fprintf(b, "var %s []*big.Int\nvar %s []*big.Int\n", c, s)
+	fmt.Fprintf(b, "var %s [][]*big.Int\nvar %s [][]*big.Int\n\n", m, p)
+
+	fmt.Fprintf(b, "func init() {\n\n")
+	fmt.Fprintf(b, "\t%s = make([]*big.Int, %d)\n", c, len(o.C))
+	fmt.Fprintf(b, "\t%s = make([]*big.Int, %d)\n\n", s, len(o.S))
+	fmt.Fprintf(b, "\t%s = make([][]*big.Int, %d)\n", m, params.Width)
+	fmt.Fprintf(b, "\t%s = make([][]*big.Int, %d)\n\n", p, params.Width)
+	fmt.Fprintf(b, "\tfor i := 0; i < %d; i++ {\n", params.Width)
+	fmt.Fprintf(b, "\t\t%s[i] = make([]*big.Int, %d)\n", m, params.Width)
+	fmt.Fprintf(b, "\t\t%s[i] = make([]*big.Int, %d)\n\t}\n", p, params.Width)
+
+	set := func(lhs string, v *big.Int) {
+		fmt.Fprintf(b, "\t%s, _ = new(big.Int).SetString(\"%s\", 10)\n", lhs, v)
+	}
+	for i, v := range o.C {
+		set(fmt.Sprintf("%s[%d]", c, i), v)
+	}
+	for i, v := range o.S {
+		set(fmt.Sprintf("%s[%d]", s, i), v)
+	}
+	for _, matrix := range []struct {
+		name   string
+		values [][]*big.Int
+	}{{m, o.M}, {p, o.P}} {
+		for i := range matrix.values {
+			for j, v := range matrix.values[i] {
+				set(fmt.Sprintf("%s[%d][%d]", matrix.name, i, j), v)
+			}
+		}
+	}
+	fmt.Fprintf(b, "}\n")
+	return b.Flush()
+}
+
+// Verify checks that the file at path holds exactly the source WriteGo
+// writes for params, pkg and name, and reports the first line which
+// differs otherwise.
+func Verify(path string, params *poseidon.Params, pkg, name string) error {
+	var expected bytes.Buffer
+	if err := WriteGo(&expected, params, pkg, name); err != nil {
+		return err
+	}
+	actual, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(actual, expected.Bytes()) {
+		return nil
+	}
+
+	expectedLines := strings.Split(expected.String(), "\n")
+	actualLines := strings.Split(string(actual), "\n")
+	for i := 0; i < max(len(expectedLines), len(actualLines)); i++ {
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a {
+			return fmt.Errorf("%s:%d differs from the generated parameters:\n\thave %q\n\twant %q", path, i+1, a, e)
+		}
+	}
+	return fmt.Errorf("%s differs from the generated parameters", path)
+}
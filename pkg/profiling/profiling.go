@@ -0,0 +1,147 @@
+// Package profiling attributes the constraints and lookup entries of a
+// circuit to named regions of its Define method.
+//
+// A region is delimited with Region:
+//
+//	err := profiling.Region(api, "Hash 1", func() error {
+//		...
+//	})
+//
+// Regions only record anything when the circuit is compiled with a builder
+// wrapped by Wrap; otherwise Region simply calls fn. Constraints are counted
+// with gnark's profile package, which is not thread safe: only one circuit
+// may be compiled at a time while profiling.
+package profiling
+
+import (
+	"fmt"
+	"gnark-circuit-gen/pkg/builders"
+	"strings"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/profile"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// Stats are the costs attributed to a region. Nested regions are also
+// accounted to their parents.
+type Stats struct {
+	Name          string
+	Constraints   int
+	LookupEntries int
+}
+
+// Builder wraps a frontend.Builder to record the regions entered while
+// compiling.
+type Builder struct {
+	builders.Wrapper
+
+	checker  frontend.Rangechecker
+	nbChecks int
+
+	stack   []string
+	regions map[string]*Stats
+	order   []string
+}
+
+// Wrap returns builder augmented with region tracking, to be handed to the
+// compiler through builders.Forward. Range checks are forwarded to the
+// range checker rangecheck.New selects for builder, so the lookups
+// exported from it are left untouched.
+func Wrap(builder frontend.Builder) *Builder {
+	return &Builder{Wrapper: builders.Wrapper{Builder: builder}, regions: map[string]*Stats{}}
+}
+
+// Check implements frontend.Rangechecker.
+func (b *Builder) Check(v frontend.Variable, bits int) {
+	if b.checker == nil {
+		b.checker = rangecheck.New(b.Builder)
+	}
+	b.nbChecks++
+	b.checker.Check(v, bits)
+}
+
+// Regions returns the statistics of every region, in the order they were
+// first entered, parents before their children. Regions entered several
+// times are accumulated.
+func (b *Builder) Regions() []Stats {
+	res := make([]Stats, len(b.order))
+	for i, name := range b.order {
+		res[i] = *b.regions[name]
+	}
+	return res
+}
+
+// NbLookupEntries returns the number of range checks requested so far.
+func (b *Builder) NbLookupEntries() int {
+	return b.nbChecks
+}
+
+func (b *Builder) enter(name string) func() {
+	b.stack = append(b.stack, name)
+	path := strings.Join(b.stack, "/")
+
+	stats, ok := b.regions[path]
+	if !ok {
+		stats = &Stats{Name: path}
+		b.regions[path] = stats
+		b.order = append(b.order, path)
+	}
+
+	session := profile.Start(profile.WithNoOutput())
+	nbChecks := b.nbChecks
+
+	return func() {
+		session.Stop()
+		b.stack = b.stack[:len(b.stack)-1]
+
+		stats.Constraints += session.NbConstraints()
+		stats.LookupEntries += b.nbChecks - nbChecks
+	}
+}
+
+// Region runs fn, attributing the constraints and range checks it creates to
+// name when api is a *Builder, as returned by builders.Forward. Regions can
+// be nested, nested regions are reported as "parent/child".
+func Region(api frontend.API, name string, fn func() error) error {
+	b, ok := builders.Unwrap(api).(*Builder)
+	if !ok {
+		return fn()
+	}
+	defer b.enter(name)()
+	return fn()
+}
+
+// Table formats regions as a plain-text table. total and totalLookups are
+// the counts of the whole circuit, the remainder being reported as outside
+// of any region.
+func Table(regions []Stats, total int, totalLookups int) string {
+	var sb strings.Builder
+
+	width := len("(outside regions)")
+	for _, r := range regions {
+		width = max(width, len(r.Name))
+	}
+	row := func(name string, constraints, lookups int) {
+		share := 0.
+		if total > 0 {
+			share = 100 * float64(constraints) / float64(total)
+		}
+		fmt.Fprintf(&sb, "%-*s  %12d  %6.2f%%  %14d\n", width, name, constraints, share, lookups)
+	}
+
+	fmt.Fprintf(&sb, "%-*s  %12s  %7s  %14s\n", width, "region", "constraints", "share", "lookup entries")
+
+	outside, outsideLookups := total, totalLookups
+	for _, r := range regions {
+		row(r.Name, r.Constraints, r.LookupEntries)
+		if !strings.Contains(r.Name, "/") {
+			outside -= r.Constraints
+			outsideLookups -= r.LookupEntries
+		}
+	}
+	row("(outside regions)", outside, outsideLookups)
+	row("total", total, totalLookups)
+
+	return sb.String()
+}
@@ -0,0 +1,210 @@
+// Package stats computes size statistics of exported constraint systems.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gnark-circuit-gen/pkg/export"
+	"gnark-circuit-gen/pkg/utils"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Report is the content of stats.json.
+type Report struct {
+	// Backend is "r1cs" or "scs", after the constraint system file found.
+	Backend             string `json:"backend"`
+	NbConstraints       int    `json:"nb_constraints"`
+	NbPublicVariables   int    `json:"nb_public_variables"`
+	NbPublicInputs      int    `json:"nb_public_inputs"`
+	NbSecretVariables   int    `json:"nb_secret_variables"`
+	NbInternalVariables int    `json:"nb_internal_variables"`
+	// NbCoefficients is the number of distinct coefficients used by the
+	// constraints.
+	NbCoefficients int `json:"nb_coefficients"`
+	// NonZeros counts the non-zero entries of the A, B and C matrices, or of
+	// the qL, qR, qO, qM and qC selectors.
+	NonZeros map[string]int `json:"non_zeros"`
+	// MaxRowWeight and MeanRowWeight are computed over the number of
+	// non-zeros of each constraint.
+	MaxRowWeight  int          `json:"max_row_weight"`
+	MeanRowWeight float64      `json:"mean_row_weight"`
+	Lookup        []TableStats `json:"lookup"`
+	Timings       *Timings     `json:"timings,omitempty"`
+}
+
+// TableStats counts the lookup entries of a table.
+type TableStats struct {
+	Bits    int `json:"bits"`
+	Entries int `json:"entries"`
+}
+
+// Timings are the wall-clock durations of the phases of a run.
+type Timings struct {
+	Compile Duration `json:"compile"`
+	Solve   Duration `json:"solve"`
+	Export  Duration `json:"export"`
+}
+
+// Duration is a time.Duration encoded in JSON as seconds.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Seconds())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// FromR1CS computes the report of an R1CS.
+func FromR1CS(r1cs *export.R1CS) *Report {
+	r := &Report{
+		Backend:             "r1cs",
+		NbConstraints:       len(r1cs.A),
+		NbPublicVariables:   r1cs.NbPublic,
+		NbPublicInputs:      r1cs.NbPublic - 1, // the constant one wire
+		NbSecretVariables:   r1cs.NbSecret,
+		NbInternalVariables: r1cs.NbInternal,
+		NonZeros:            map[string]int{},
+	}
+
+	coeffs := coefficientSet{}
+	total := 0
+	for i := range r1cs.A {
+		weight := 0
+		for name, row := range map[string][]export.Term{"A": r1cs.A[i], "B": r1cs.B[i], "C": r1cs.C[i]} {
+			for _, t := range row {
+				coeffs.add(t.Coeff)
+				if t.Coeff.Sign() != 0 {
+					r.NonZeros[name]++
+					weight++
+				}
+			}
+		}
+		r.MaxRowWeight = max(r.MaxRowWeight, weight)
+		total += weight
+	}
+	r.NbCoefficients = len(coeffs)
+	if r.NbConstraints > 0 {
+		r.MeanRowWeight = float64(total) / float64(r.NbConstraints)
+	}
+	return r
+}
+
+// FromSparseR1CS computes the report of a SparseR1CS.
+func FromSparseR1CS(scs *export.SparseR1CS) *Report {
+	r := &Report{
+		Backend:             "scs",
+		NbConstraints:       len(scs.Gates),
+		NbPublicVariables:   scs.NbPublic,
+		NbPublicInputs:      scs.NbPublic,
+		NbSecretVariables:   scs.NbSecret,
+		NbInternalVariables: scs.NbInternal,
+		NonZeros:            map[string]int{},
+	}
+
+	coeffs := coefficientSet{}
+	total := 0
+	for _, g := range scs.Gates {
+		weight := 0
+		for name, q := range map[string]*big.Int{"qL": g.QL, "qR": g.QR, "qO": g.QO, "qM": g.QM, "qC": g.QC} {
+			coeffs.add(q)
+			if q.Sign() != 0 {
+				r.NonZeros[name]++
+				weight++
+			}
+		}
+		r.MaxRowWeight = max(r.MaxRowWeight, weight)
+		total += weight
+	}
+	r.NbCoefficients = len(coeffs)
+	if r.NbConstraints > 0 {
+		r.MeanRowWeight = float64(total) / float64(r.NbConstraints)
+	}
+	return r
+}
+
+// AddLookup records the number of entries of every table of lookup.
+func (r *Report) AddLookup(lookup *export.Lookup) {
+	r.Lookup = make([]TableStats, len(lookup.Tables))
+	for i, table := range lookup.Tables {
+		r.Lookup[i] = TableStats{Bits: table.Bits, Entries: len(table.Entries)}
+	}
+}
+
+// FromDir computes the report of the artifacts of a run directory. The
+// timings are taken from its stats.json when there is one.
+func FromDir(dir string) (*Report, error) {
+	var r *Report
+
+	scsPath := filepath.Join(dir, "scs.cbor")
+	if _, err := os.Stat(scsPath); err == nil {
+		var scs export.SparseR1CS
+		if err := export.ReadCBOR(scsPath, &scs); err != nil {
+			return nil, fmt.Errorf("scs.cbor: %w", err)
+		}
+		r = FromSparseR1CS(&scs)
+	} else {
+		var r1cs export.R1CS
+		if err := export.ReadCBOR(filepath.Join(dir, "r1cs.cbor"), &r1cs); err != nil {
+			return nil, fmt.Errorf("r1cs.cbor: %w", err)
+		}
+		r = FromR1CS(&r1cs)
+	}
+
+	var lookup export.Lookup
+	err := export.ReadCBOR(filepath.Join(dir, "lookup.cbor"), &lookup)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("lookup.cbor: %w", err)
+	}
+	r.AddLookup(&lookup)
+
+	if previous, err := Read(dir); err == nil {
+		r.Timings = previous.Timings
+	}
+	return r, nil
+}
+
+// Read reads the stats.json of a run directory.
+func Read(dir string) (*Report, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "stats.json"))
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes the report to the stats.json of a run directory.
+func (r *Report) Write(dir string) error {
+	data, err := r.JSON()
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(dir, "stats.json"), func(path string) error {
+		return os.WriteFile(path, data, 0o644)
+	})
+}
+
+// JSON returns the indented JSON encoding of the report.
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+type coefficientSet map[string]struct{}
+
+func (s coefficientSet) add(c *big.Int) {
+	s[c.String()] = struct{}{}
+}
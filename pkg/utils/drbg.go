@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+)
+
+// drbg is a deterministic random byte generator producing the stream
+// SHA256(seed || 0) || SHA256(seed || 1) || ...
+type drbg struct {
+	seed    [8]byte
+	counter uint64
+	buf     []byte
+}
+
+// NewSeededReader returns a reader producing the same byte stream on every
+// run for a given seed. It must only be used to make test data reproducible,
+// never to generate secrets.
+func NewSeededReader(seed uint64) io.Reader {
+	r := &drbg{}
+	binary.BigEndian.PutUint64(r.seed[:], seed)
+	return r
+}
+
+func (r *drbg) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			var block [16]byte
+			copy(block[:8], r.seed[:])
+			binary.BigEndian.PutUint64(block[8:], r.counter)
+			r.counter++
+
+			digest := sha256.Sum256(block[:])
+			r.buf = digest[:]
+		}
+		c := copy(p[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// RandSeed draws a fresh seed from crypto/rand.
+func RandSeed() (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteFileAtomic lets write produce the file at a temporary path in the
+// directory of path and renames it into place only once write succeeded, so
+// that an interrupted run never leaves a truncated file behind.
+func WriteFileAtomic(path string, write func(tmpPath string) error) (err error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	// os.CreateTemp creates the file readable by its owner only
+	if err = os.Chmod(tmpPath, 0o644); err != nil {
+		return
+	}
+
+	if err = write(tmpPath); err != nil {
+		return
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// CreateRunDir creates and returns a fresh directory <root>/<name>/<run-id>,
+// where run-id is derived from the current time. A numeric suffix is added to
+// the run-id when several runs start within the same second.
+func CreateRunDir(root string, name string) (string, error) {
+	parent := filepath.Join(root, name)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", err
+	}
+
+	runID := time.Now().UTC().Format("20060102-150405")
+	for i := 0; ; i++ {
+		dir := filepath.Join(parent, runID)
+		if i > 0 {
+			dir = fmt.Sprintf("%s-%d", dir, i)
+		}
+
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+}
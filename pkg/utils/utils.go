@@ -1,22 +1,22 @@
 package utils
 
 import (
-	"crypto/rand"
 	"io"
 	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/secp256k1"
 	"github.com/consensys/gnark-crypto/ecc/secp256k1/ecdsa"
+	"github.com/consensys/gnark/std/math/emulated"
 )
 
-func RandP256G() (secp256k1.G1Affine, error) {
-	privKey, err := ecdsa.GenerateKey(rand.Reader)
+func RandP256G(rand io.Reader) (secp256k1.G1Affine, error) {
+	privKey, err := ecdsa.GenerateKey(rand)
 	return privKey.PublicKey.A, err
 }
 
-func RandFieldElement(modulus *big.Int) (k *big.Int, err error) {
+func RandFieldElement(rand io.Reader, modulus *big.Int) (k *big.Int, err error) {
 	b := make([]byte, modulus.BitLen()/8+8)
-	_, err = io.ReadFull(rand.Reader, b)
+	_, err = io.ReadFull(rand, b)
 	if err != nil {
 		return
 	}
@@ -31,11 +31,11 @@ func RandFieldElement(modulus *big.Int) (k *big.Int, err error) {
 	return
 }
 
-func Rand128Bit() (*big.Int, error) {
+func Rand128Bit(rand io.Reader) (*big.Int, error) {
 	res := new(big.Int)
 
 	b := make([]byte, 128/8)
-	_, err := io.ReadFull(rand.Reader, b)
+	_, err := io.ReadFull(rand, b)
 	if err != nil {
 		return res, err
 	}
@@ -44,3 +44,33 @@ func Rand128Bit() (*big.Int, error) {
 
 	return res, nil
 }
+
+// NextPrime returns the smallest probable prime strictly greater than n.
+func NextPrime(n *big.Int) *big.Int {
+	p := new(big.Int).Add(n, big.NewInt(1))
+	if p.Cmp(big.NewInt(2)) <= 0 {
+		return big.NewInt(2)
+	}
+	if p.Bit(0) == 0 {
+		p.Add(p, big.NewInt(1))
+	}
+	for !p.ProbablyPrime(20) {
+		p.Add(p, big.NewInt(2))
+	}
+	return p
+}
+
+// Limbs decomposes v, reduced modulo the emulated field T, into its limbs,
+// least significant first, as emulated.ValueOf does.
+func Limbs[T emulated.FieldParams](v *big.Int) []*big.Int {
+	var field T
+	r := new(big.Int).Mod(v, field.Modulus())
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), field.BitsPerLimb()), big.NewInt(1))
+
+	limbs := make([]*big.Int, field.NbLimbs())
+	for i := range limbs {
+		limbs[i] = new(big.Int).And(r, mask)
+		r.Rsh(r, field.BitsPerLimb())
+	}
+	return limbs
+}
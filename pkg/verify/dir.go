@@ -0,0 +1,70 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"gnark-circuit-gen/pkg/export"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark/logger"
+)
+
+// Dir checks the artifacts of a run directory: the constraint system
+// (r1cs.cbor, or scs.cbor when present) against every assignment listed in
+// assignments.json, and every entry of lookup.cbor against its table.
+func Dir(dir string, modulus *big.Int) error {
+	log := logger.Logger().With().Logger()
+
+	var check func(w []*big.Int) error
+
+	scsPath := filepath.Join(dir, "scs.cbor")
+	if _, err := os.Stat(scsPath); err == nil {
+		scs := &export.SparseR1CS{}
+		if err := export.ReadCBOR(scsPath, scs); err != nil {
+			return fmt.Errorf("scs.cbor: %w", err)
+		}
+		check = func(w []*big.Int) error { return SparseR1CS(scs, w, modulus) }
+		log.Info().Msgf("checking %d gates", len(scs.Gates))
+	} else {
+		r1cs := &export.R1CS{}
+		if err := export.ReadCBOR(filepath.Join(dir, "r1cs.cbor"), r1cs); err != nil {
+			return fmt.Errorf("r1cs.cbor: %w", err)
+		}
+		check = func(w []*big.Int) error { return R1CS(r1cs, w, modulus) }
+		log.Info().Msgf("checking %d constraints", len(r1cs.A))
+	}
+
+	lookup := &export.Lookup{}
+	err := export.ReadCBOR(filepath.Join(dir, "lookup.cbor"), lookup)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Info().Msg("no lookup.cbor, skipping lookup checks")
+	} else if err != nil {
+		return fmt.Errorf("lookup.cbor: %w", err)
+	}
+	for _, table := range lookup.Tables {
+		log.Info().Msgf("checking %d lookup entries in [0, 2^%d)", len(table.Entries), table.Bits)
+	}
+
+	index, err := export.ReadAssignmentIndex(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range index.Files {
+		var assignment export.Assignment
+		if err := export.ReadCBOR(filepath.Join(dir, file), &assignment); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		w := assignment.Values()
+
+		if err := check(w); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if err := Lookup(lookup, w, modulus); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		log.Info().Msgf("%s satisfies every constraint and lookup entry", file)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Package verify checks exported artifacts with plain big.Int arithmetic,
+// independently of gnark's solver.
+package verify
+
+import (
+	"fmt"
+	"gnark-circuit-gen/pkg/export"
+	"math/big"
+	"strings"
+)
+
+// ConstraintError reports the first constraint not satisfied by an
+// assignment.
+type ConstraintError struct {
+	Index int
+	// Rows holds the terms of the constraint, A, B and C for R1CS and the
+	// single gate row for SparseR1CS.
+	Rows []Row
+}
+
+// Row is a linear combination of a failing constraint together with the
+// values it was evaluated on.
+type Row struct {
+	Name   string
+	Terms  []export.Term
+	Values []*big.Int
+	Sum    *big.Int
+}
+
+func (e *ConstraintError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "constraint %d is not satisfied", e.Index)
+	for _, row := range e.Rows {
+		fmt.Fprintf(&sb, "\n  %s = %s", row.Name, row.Sum)
+		for i, t := range row.Terms {
+			fmt.Fprintf(&sb, "\n    %s * w[%d] (= %s)", t.Coeff, t.Wire, row.Values[i])
+		}
+	}
+	return sb.String()
+}
+
+// LookupError reports the first lookup entry outside of its table.
+type LookupError struct {
+	Table int
+	Bits  int
+	Index int
+	Entry export.LookupEntry
+	Value *big.Int
+}
+
+func (e *LookupError) Error() string {
+	return fmt.Sprintf("lookup entry %d of table %d: %s * w[%d] = %s does not fit in %d bits",
+		e.Index, e.Table, e.Entry.Coeff, e.Entry.Wire, e.Value, e.Bits)
+}
+
+// R1CS checks ⟨A_i,z⟩·⟨B_i,z⟩ = ⟨C_i,z⟩ mod modulus for every row i and
+// returns a *ConstraintError for the first one which does not hold.
+func R1CS(r1cs *export.R1CS, z []*big.Int, modulus *big.Int) error {
+	if len(z) != r1cs.NbWires() {
+		return fmt.Errorf("assignment has %d wires, r1cs expects %d", len(z), r1cs.NbWires())
+	}
+	if len(r1cs.A) != len(r1cs.B) || len(r1cs.A) != len(r1cs.C) {
+		return fmt.Errorf("matrices have %d, %d and %d rows", len(r1cs.A), len(r1cs.B), len(r1cs.C))
+	}
+
+	ab := new(big.Int)
+	for i := range r1cs.A {
+		a, err := evaluate(r1cs.A[i], z, modulus)
+		if err != nil {
+			return fmt.Errorf("constraint %d: %w", i, err)
+		}
+		b, err := evaluate(r1cs.B[i], z, modulus)
+		if err != nil {
+			return fmt.Errorf("constraint %d: %w", i, err)
+		}
+		c, err := evaluate(r1cs.C[i], z, modulus)
+		if err != nil {
+			return fmt.Errorf("constraint %d: %w", i, err)
+		}
+
+		ab.Mul(a, b).Mod(ab, modulus)
+		if ab.Cmp(c) != 0 {
+			return &ConstraintError{Index: i, Rows: []Row{
+				newRow("A", r1cs.A[i], z, a),
+				newRow("B", r1cs.B[i], z, b),
+				newRow("C", r1cs.C[i], z, c),
+			}}
+		}
+	}
+	return nil
+}
+
+// SparseR1CS checks qL⋅l + qR⋅r + qO⋅o + qM⋅l⋅r + qC = 0 mod modulus for
+// every gate and returns a *ConstraintError for the first one which does
+// not hold.
+func SparseR1CS(scs *export.SparseR1CS, w []*big.Int, modulus *big.Int) error {
+	nbWires := scs.NbPublic + scs.NbSecret + scs.NbInternal
+	if len(w) != nbWires {
+		return fmt.Errorf("assignment has %d wires, scs expects %d", len(w), nbWires)
+	}
+
+	for i, g := range scs.Gates {
+		if int(g.L) >= len(w) || int(g.R) >= len(w) || int(g.O) >= len(w) {
+			return fmt.Errorf("gate %d: wire out of range", i)
+		}
+		res := new(big.Int).Mul(g.QM, w[g.L])
+		res.Mul(res, w[g.R])
+		res.Add(res, new(big.Int).Mul(g.QL, w[g.L]))
+		res.Add(res, new(big.Int).Mul(g.QR, w[g.R]))
+		res.Add(res, new(big.Int).Mul(g.QO, w[g.O]))
+		res.Add(res, g.QC)
+		if res.Mod(res, modulus).Sign() != 0 {
+			terms := []export.Term{{Wire: g.L, Coeff: g.QL}, {Wire: g.R, Coeff: g.QR}, {Wire: g.O, Coeff: g.QO}}
+			return &ConstraintError{Index: i, Rows: []Row{
+				newRow(fmt.Sprintf("gate (qM = %s, qC = %s)", g.QM, g.QC), terms, w, res),
+			}}
+		}
+	}
+	return nil
+}
+
+// Lookup checks that every entry of every table lies in [0, 2^Bits) and
+// returns a *LookupError for the first one which does not.
+func Lookup(lookup *export.Lookup, w []*big.Int, modulus *big.Int) error {
+	for i, table := range lookup.Tables {
+		for j, entry := range table.Entries {
+			if int(entry.Wire) >= len(w) {
+				return fmt.Errorf("lookup entry %d of table %d: wire %d out of range", j, i, entry.Wire)
+			}
+			v := new(big.Int).Mul(entry.Coeff, w[entry.Wire])
+			v.Mod(v, modulus)
+			if v.BitLen() > table.Bits {
+				return &LookupError{Table: i, Bits: table.Bits, Index: j, Entry: entry, Value: v}
+			}
+		}
+	}
+	return nil
+}
+
+func evaluate(terms []export.Term, z []*big.Int, modulus *big.Int) (*big.Int, error) {
+	res, tmp := new(big.Int), new(big.Int)
+	for _, t := range terms {
+		if int(t.Wire) >= len(z) {
+			return nil, fmt.Errorf("wire %d out of range", t.Wire)
+		}
+		res.Add(res, tmp.Mul(t.Coeff, z[t.Wire]))
+	}
+	return res.Mod(res, modulus), nil
+}
+
+func newRow(name string, terms []export.Term, z []*big.Int, sum *big.Int) Row {
+	values := make([]*big.Int, len(terms))
+	for i, t := range terms {
+		values[i] = z[t.Wire]
+	}
+	return Row{Name: name, Terms: terms, Values: values, Sum: sum}
+}